@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// presetKeys are the keys of a preset in a catalogue file, each setting a field of
+// the preset once the value has been checked
+var presetKeys = map[string]func(p *smoothlife.Preset, v any) error{
+	"description":      presetString(func(p *smoothlife.Preset, s string) error { p.Description = s; return nil }),
+	"inner_radius":     presetNumber(positive, func(p *smoothlife.Preset, f float64) { p.InnerRadius = f }),
+	"outer_radius":     presetNumber(positive, func(p *smoothlife.Preset, f float64) { p.OuterRadius = f }),
+	"b1":               presetNumber(unitInterval, func(p *smoothlife.Preset, f float64) { p.Rule.B1 = f }),
+	"b2":               presetNumber(unitInterval, func(p *smoothlife.Preset, f float64) { p.Rule.B2 = f }),
+	"d1":               presetNumber(unitInterval, func(p *smoothlife.Preset, f float64) { p.Rule.D1 = f }),
+	"d2":               presetNumber(unitInterval, func(p *smoothlife.Preset, f float64) { p.Rule.D2 = f }),
+	"n":                presetNumber(nonNegative, func(p *smoothlife.Preset, f float64) { p.Rule.N = f }),
+	"m":                presetNumber(nonNegative, func(p *smoothlife.Preset, f float64) { p.Rule.M = f }),
+	"alive_sigmoid":    presetSigmoid(func(p *smoothlife.Preset) *smoothlife.Sigmoid { return &p.Rule.AliveSigmoid }),
+	"interval_sigmoid": presetSigmoid(func(p *smoothlife.Preset) *smoothlife.Sigmoid { return &p.Rule.IntervalSigmoid }),
+	"mix_sigmoid":      presetSigmoid(func(p *smoothlife.Preset) *smoothlife.Sigmoid { return &p.Rule.MixSigmoid }),
+	"mode": presetString(func(p *smoothlife.Preset, s string) (err error) {
+		p.TimeStep.Mode, err = smoothlife.ParseTimeStepMode(s)
+		return err
+	}),
+	"integrator": presetString(func(p *smoothlife.Preset, s string) (err error) {
+		p.TimeStep.Integrator, err = smoothlife.ParseIntegrator(s)
+		return err
+	}),
+	"dt": presetNumber(timeStep, func(p *smoothlife.Preset, f float64) { p.TimeStep.Dt = f }),
+}
+
+func presetString(set func(p *smoothlife.Preset, s string) error) func(p *smoothlife.Preset, v any) error {
+	return func(p *smoothlife.Preset, v any) error {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, got %s", describe(v))
+		}
+		return set(p, s)
+	}
+}
+
+func presetNumber(check func(v any) error, set func(p *smoothlife.Preset, f float64)) func(p *smoothlife.Preset, v any) error {
+	return func(p *smoothlife.Preset, v any) error {
+		f, ok := number(v)
+		if !ok {
+			return fmt.Errorf("must be a number, got %s", describe(v))
+		}
+		if err := check(v); err != nil {
+			return err
+		}
+		set(p, f)
+		return nil
+	}
+}
+
+func presetSigmoid(field func(p *smoothlife.Preset) *smoothlife.Sigmoid) func(p *smoothlife.Preset, v any) error {
+	return presetString(func(p *smoothlife.Preset, s string) (err error) {
+		*field(p), err = smoothlife.ParseSigmoid(s)
+		return err
+	})
+}
+
+// loadCatalogue reads a JSON or TOML file of presets and registers them. Each
+// preset is a table under presets, named by its key, that starts from the preset
+// named by its base key, or from the default options without one, and changes the
+// keys it gives. A preset may be loaded again with the same settings, but not
+// redefine another. Every problem is reported with the line it is on.
+func loadCatalogue(path string) error {
+	tree, lines, err := decodeConfigFile(path)
+	if err != nil {
+		return err
+	}
+	var problems []configProblem
+	report := func(key string, err error) {
+		problems = append(problems, configProblem{line: lineOf(lines, key), key: key, err: err})
+	}
+	for name := range tree {
+		if name != "presets" {
+			report(name, errors.New("unknown key, presets belong in the presets table"))
+		}
+	}
+	presets, ok := tree["presets"].(map[string]any)
+	if !ok {
+		if _, given := tree["presets"]; given {
+			report("presets", fmt.Errorf("must be a table, got %s", describe(tree["presets"])))
+		}
+		return joinProblems(path, problems)
+	}
+
+	var loaded []smoothlife.Preset
+	for name, v := range presets {
+		key := "presets." + name
+		table, ok := v.(map[string]any)
+		if !ok {
+			report(key, fmt.Errorf("must be a table, got %s", describe(v)))
+			continue
+		}
+		preset, err := catalogueBase(table["base"])
+		if err != nil {
+			report(key+".base", err)
+			continue
+		}
+		preset.Name = name
+		bad := false
+		for k, v := range table {
+			if k == "base" {
+				continue
+			}
+			set, ok := presetKeys[k]
+			if !ok {
+				report(key+"."+k, errors.New("unknown key"))
+				bad = true
+				continue
+			}
+			if err := set(&preset, v); err != nil {
+				report(key+"."+k, err)
+				bad = true
+			}
+		}
+		if bad {
+			continue
+		}
+		if err := preset.Validate(); err != nil {
+			report(key, err)
+			continue
+		}
+		if existing, err := smoothlife.LookupPreset(name); err == nil && existing != preset {
+			report(key, errors.New("a preset of that name already exists"))
+			continue
+		}
+		loaded = append(loaded, preset)
+	}
+	if len(problems) > 0 {
+		return joinProblems(path, problems)
+	}
+	for _, p := range loaded {
+		if _, err := smoothlife.LookupPreset(p.Name); err != nil {
+			smoothlife.RegisterPreset(p)
+		}
+	}
+	return nil
+}
+
+// catalogueBase is the preset a catalogue entry starts from
+func catalogueBase(base any) (smoothlife.Preset, error) {
+	if base == nil {
+		defaults := smoothlife.DefaultOptions()
+		return smoothlife.Preset{
+			InnerRadius: defaults.InnerRadius,
+			OuterRadius: defaults.OuterRadius,
+			Rule:        defaults.Rule.(smoothlife.BasicRules),
+			TimeStep:    defaults.TimeStep,
+		}, nil
+	}
+	name, ok := base.(string)
+	if !ok {
+		return smoothlife.Preset{}, fmt.Errorf("must be a string, got %s", describe(base))
+	}
+	p, err := smoothlife.LookupPreset(name)
+	if err != nil {
+		return p, fmt.Errorf("%w, choose one of %s", err, strings.Join(smoothlife.PresetNames(), ", "))
+	}
+	// The description belongs to the base
+	p.Description = ""
+	return p, nil
+}
+
+// catalogueFlag collects the catalogue files given by repeating -catalogue
+type catalogueFlag []string
+
+func (c *catalogueFlag) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(*c, ",")
+}
+
+func (c *catalogueFlag) Set(path string) error {
+	*c = append(*c, path)
+	return nil
+}
+
+func (c *catalogueFlag) Get() any { return []string(*c) }
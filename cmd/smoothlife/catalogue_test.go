@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// writeCatalogue writes a catalogue file and returns its path
+func writeCatalogue(t *testing.T, name, catalogue string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(catalogue), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func parseOptions(t *testing.T, args ...string) (smoothlife.Options, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	simFlags := addSimulationFlags(fs)
+	if err := simFlags.parse(args); err != nil {
+		return smoothlife.Options{}, err
+	}
+	return simFlags.options()
+}
+
+func TestPresetFlag(t *testing.T) {
+	smooth, err := smoothlife.LookupPreset("duckythescientist-smooth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := parseOptions(t, "-preset", "duckythescientist-smooth", "-d2", "0.5", "-outer", "13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := smooth.Rule
+	want.D2 = 0.5
+	if opts.Rule != want {
+		t.Errorf("rule %+v; want the preset's with d2 0.5", opts.Rule)
+	}
+	if opts.InnerRadius != smooth.InnerRadius || opts.OuterRadius != 13 || opts.TimeStep != smooth.TimeStep {
+		t.Errorf("options %+v do not match the preset with the outer radius given", opts)
+	}
+
+	// The preset wins over the keys of a config, and the command line over both
+	example := filepath.Join("..", "..", "configs", "example.toml")
+	opts, err = parseOptions(t, "-config", example, "-preset", "duckythescientist-smooth", "-dt", "0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = smooth.Rule
+	wantStep := smooth.TimeStep
+	wantStep.Dt = 0.2
+	if opts.Rule != want || opts.InnerRadius != smooth.InnerRadius || opts.OuterRadius != smooth.OuterRadius || opts.TimeStep != wantStep {
+		t.Errorf("options %+v from the example config do not match the preset with dt 0.2", opts)
+	}
+	config := writeCatalogue(t, "config.toml", "[grid]\nwidth = 64\n\n[rule]\npreset = \"duckythescientist-smooth\"\nb1 = 0.3\n")
+	if opts, err = parseOptions(t, "-config", config); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Width != 64 || opts.Rule != smooth.Rule || opts.OuterRadius != smooth.OuterRadius {
+		t.Errorf("options %+v do not match the preset named in the config", opts)
+	}
+
+	if _, err := parseOptions(t, "-preset", "missing"); err == nil || !strings.Contains(err.Error(), "duckythescientist-smooth") {
+		t.Errorf("an unknown preset gave %v; want the names to choose from", err)
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+	path := writeCatalogue(t, "mine.toml", `
+[presets.test-slow]
+base = "duckythescientist-smooth"
+description = "slower smooth steps"
+dt = 0.05
+
+[presets.test-wide]
+inner_radius = 6
+outer_radius = 24
+mode = "smooth-relax"
+`)
+	config := writeCatalogue(t, "config.toml", "[rule]\npreset = \"test-wide\"\ncatalogue = \""+filepath.ToSlash(path)+"\"\n")
+
+	cases := []struct {
+		name string
+		args []string
+		want func(opts smoothlife.Options) bool
+	}{
+		{"Based on a preset", []string{"-catalogue", path, "-preset", "test-slow"}, func(opts smoothlife.Options) bool {
+			return opts.OuterRadius == 21 && opts.TimeStep.Mode == smoothlife.SmoothSigned && opts.TimeStep.Dt == 0.05
+		}},
+		{"Based on the defaults", []string{"-catalogue", path, "-preset", "test-wide"}, func(opts smoothlife.Options) bool {
+			return opts.OuterRadius == 24 && opts.TimeStep.Mode == smoothlife.SmoothRelax && opts.Rule == smoothlife.DefaultOptions().Rule
+		}},
+		{"From a config", []string{"-config", config}, func(opts smoothlife.Options) bool {
+			return opts.OuterRadius == 24
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := parseOptions(t, tc.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.want(opts) {
+				t.Errorf("options %+v do not match the preset", opts)
+			}
+		})
+	}
+
+	p, err := smoothlife.LookupPreset("test-slow")
+	if err != nil || p.Description != "slower smooth steps" {
+		t.Errorf("registered %+v, %v", p, err)
+	}
+}
+
+func TestCatalogueErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		file      string
+		catalogue string
+		want      []string
+	}{
+		{"Clash with a built in preset", "bad.toml", "[presets.paper]\ndt = 0.2\n", []string{"bad.toml:1: presets.paper: a preset of that name already exists"}},
+		{"Unknown key", "bad.toml", "[presets.test-bad]\ninner_radius = 3\nouter = 9\n", []string{"bad.toml:3: presets.test-bad.outer: unknown key"}},
+		{"Unknown base", "bad.toml", "[presets.test-bad]\n\nbase = \"papr\"\n", []string{`bad.toml:3: presets.test-bad.base: smoothlife: unknown preset "papr"`}},
+		{"Wrong type", "bad.json", "{\"presets\": {\"test-bad\": {\n\"b1\": \"low\"}}}", []string{`bad.json:2: presets.test-bad.b1: must be a number, got "low"`}},
+		{"Invalid preset", "bad.toml", "[presets.test-bad]\ninner_radius = 70\n", []string{"bad.toml:1: presets.test-bad: smoothlife: preset \"test-bad\""}},
+		{"Outside the presets", "bad.toml", "[preset.test-bad]\ndt = 0.2\n", []string{"bad.toml:1: preset: unknown key"}},
+		{"Every problem", "bad.toml", "[presets.test-bad]\nb1 = 2\n\n[presets.test-worse]\nmode = \"smooth\"\n", []string{"bad.toml:2: presets.test-bad.b1", "bad.toml:5: presets.test-worse.mode"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := loadCatalogue(writeCatalogue(t, tc.file, tc.catalogue))
+			if err == nil {
+				t.Fatal("catalogue was accepted")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+	for _, name := range []string{"test-bad", "test-worse"} {
+		if _, err := smoothlife.LookupPreset(name); err == nil {
+			t.Errorf("a bad catalogue registered %s", name)
+		}
+	}
+}
+
+func TestExampleCatalogue(t *testing.T) {
+	if err := loadCatalogue(filepath.Join("..", "..", "configs", "presets.toml")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"slow-smooth", "wide-paper"} {
+		if _, err := parseOptions(t, "-preset", name); err != nil {
+			t.Error(err)
+		}
+	}
+}
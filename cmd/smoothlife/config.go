@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configKey maps a key of a config file onto the flag it sets. check, when set,
+// rejects bad values while the line they came from is still known.
+type configKey struct {
+	key   string
+	flag  string
+	check func(v any) error
+}
+
+var configKeys = []configKey{
+	{"grid.width", "width", positive},
+	{"grid.height", "height", positive},
+	{"grid.channels", "channels", positive},
+	{"grid.coupling", "coupling", unitInterval},
+	{"grid.boundary", "boundary", parsed(func(s string) error { _, err := smoothlife.ParseBoundary(s); return err })},
+	{"grid.boundary_value", "boundary-value", unitInterval},
+
+	{"kernel.inner_radius", "inner", positive},
+	{"kernel.outer_radius", "outer", positive},
+	{"kernel.logres", "logres", nonNegative},
+
+	{"rule.lenia", "lenia", nil},
+	// The preset may come from the catalogue, which loads after the config
+	{"rule.preset", "preset", nil},
+	{"rule.catalogue", "catalogue", nil},
+	{"rule.b1", "b1", unitInterval},
+	{"rule.b2", "b2", unitInterval},
+	{"rule.d1", "d1", unitInterval},
+	{"rule.d2", "d2", unitInterval},
+	{"rule.n", "n", nonNegative},
+	{"rule.m", "m", nonNegative},
+	{"rule.alive_sigmoid", "alive-sigmoid", sigmoid},
+	{"rule.interval_sigmoid", "interval-sigmoid", sigmoid},
+	{"rule.mix_sigmoid", "mix-sigmoid", sigmoid},
+
+	{"time.mode", "time-step", parsed(func(s string) error { _, err := smoothlife.ParseTimeStepMode(s); return err })},
+	{"time.integrator", "integrator", parsed(func(s string) error { _, err := smoothlife.ParseIntegrator(s); return err })},
+	{"time.dt", "dt", timeStep},
+
+	{"seeding.seed", "seed", nil},
+	// The radius only scales the default sizes of the generators
+	{"seeding.init", "init", parsed(func(s string) error { _, err := smoothlife.ParseGenerator(s, 1); return err })},
+	{"seeding.image", "image", nil},
+	{"seeding.image_fit", "image-fit", parsed(func(s string) error { _, err := smoothlife.ParseFit(s); return err })},
+	{"seeding.image_filter", "image-filter", parsed(func(s string) error { _, err := smoothlife.ParseResample(s); return err })},
+	{"seeding.image_invert", "image-invert", nil},
+	{"seeding.image_threshold", "image-threshold", unitInterval},
+
+	{"render.colormap", "colormap", parsed(func(s string) error { _, err := smoothlife.ParseColormap(s); return err })},
+	{"render.mode", "render", parsed(func(s string) error { _, err := smoothlife.ParseRenderMode(s); return err })},
+	{"render.gain", "gain", positive},
+
+	{"output.dir", "out", nil},
+	{"output.steps", "steps", nonNegative},
+	{"output.every", "every", positive},
+	{"output.gif", "gif", nil},
+	{"output.delay", "delay", nonNegative},
+	{"output.npz", "npz", nil},
+
+	{"view.rate", "rate", nonNegative},
+	{"view.hud", "hud", nil},
+}
+
+// loadConfig reads a JSON or TOML config file, chosen by its extension, and sets
+// the flags of fs its keys map onto, leaving those given on the command line alone.
+// Keys of flags fs does not have, such as the output settings for the viewer, are
+// skipped. Every problem is reported with the line it is on.
+func loadConfig(path string, fs *flag.FlagSet) error {
+	tree, lines, err := decodeConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+	keys := map[string]configKey{}
+	sections := map[string]bool{}
+	for _, k := range configKeys {
+		keys[k.key] = k
+		sections[k.key[:strings.IndexByte(k.key, '.')]] = true
+	}
+
+	var problems []configProblem
+	report := func(key string, err error) {
+		problems = append(problems, configProblem{line: lineOf(lines, key), key: key, err: err})
+	}
+	set := map[string]bool{}
+	for name, value := range tree {
+		section, ok := value.(map[string]any)
+		switch {
+		case !ok:
+			report(name, errors.New("unknown key, keys belong in a section such as grid"))
+			continue
+		case !sections[name]:
+			report(name, errors.New("unknown section"))
+			continue
+		}
+		for name2, v := range section {
+			key := name + "." + name2
+			k, ok := keys[key]
+			if !ok {
+				report(key, errors.New("unknown key"))
+				continue
+			}
+			fl := fs.Lookup(k.flag)
+			if fl == nil || given[k.flag] {
+				continue
+			}
+			if err := setFromConfig(fs, fl, k, v); err != nil {
+				report(key, err)
+				continue
+			}
+			set[key] = true
+		}
+	}
+	// The radii are only checked against each other once both are known
+	if set["kernel.inner_radius"] || set["kernel.outer_radius"] {
+		inner, outer := flagNumber(fs, "inner"), flagNumber(fs, "outer")
+		if !(outer > inner) {
+			key := "kernel.outer_radius"
+			if !set[key] {
+				key = "kernel.inner_radius"
+			}
+			report(key, fmt.Errorf("the outer radius %v must be larger than the inner radius %v", outer, inner))
+		}
+	}
+	return joinProblems(path, problems)
+}
+
+// flagNumber returns the value of a float64 flag of fs
+func flagNumber(fs *flag.FlagSet, name string) float64 {
+	return fs.Lookup(name).Value.(flag.Getter).Get().(float64)
+}
+
+// joinProblems reports the problems found in a file in the order of their lines
+func joinProblems(path string, problems []configProblem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].line != problems[j].line {
+			return problems[i].line < problems[j].line
+		}
+		return problems[i].key < problems[j].key
+	})
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = fmt.Errorf("%s:%d: %s: %w", path, p.line, p.key, p.err)
+	}
+	return errors.Join(errs...)
+}
+
+// decodeConfigFile reads a JSON or TOML file, chosen by its extension, into a
+// tree of tables and the line of every key
+func decodeConfigFile(path string) (map[string]any, map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tree map[string]any
+	var lines map[string]int
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		tree, lines, err = decodeJSONConfig(data)
+	case ".toml":
+		tree, lines, err = decodeTOMLConfig(data)
+	default:
+		return nil, nil, fmt.Errorf("%s: config files must be .json or .toml, not %q", path, ext)
+	}
+	var syntax lineError
+	if errors.As(err, &syntax) {
+		return nil, nil, fmt.Errorf("%s:%d: %v", path, syntax.line, syntax.err)
+	}
+	return tree, lines, err
+}
+
+// lineError is a problem with the syntax of a config file
+type lineError struct {
+	line int
+	err  error
+}
+
+func (e lineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+type configProblem struct {
+	line int
+	key  string
+	err  error
+}
+
+// lineOf finds the line of a key, falling back on its section
+func lineOf(lines map[string]int, key string) int {
+	for ; key != ""; key = key[:max(0, strings.LastIndexByte(key, '.'))] {
+		if line, ok := lines[key]; ok {
+			return line
+		}
+	}
+	return 1
+}
+
+// setFromConfig checks that v has the type of the flag, runs the check of the key
+// and sets the flag
+func setFromConfig(fs *flag.FlagSet, fl *flag.Flag, k configKey, v any) error {
+	var value any
+	switch fl.Value.(flag.Getter).Get().(type) {
+	case bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("must be true or false, got %s", describe(v))
+		}
+		value = b
+	case int, int64:
+		i, ok := integer(v)
+		if !ok {
+			return fmt.Errorf("must be an integer, got %s", describe(v))
+		}
+		value = i
+	case float64:
+		f, ok := number(v)
+		if !ok {
+			return fmt.Errorf("must be a number, got %s", describe(v))
+		}
+		value = f
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, got %s", describe(v))
+		}
+		value = s
+	}
+	if k.check != nil {
+		if err := k.check(value); err != nil {
+			return err
+		}
+	}
+	return fs.Set(fl.Name, fmt.Sprint(value))
+}
+
+// integer and number accept the numbers of both decoders: json.Number from JSON,
+// int64 and float64 from TOML
+func integer(v any) (int64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	}
+	return 0, false
+}
+
+func describe(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]any:
+		return "a table"
+	case []any, []map[string]any:
+		return "a list"
+	}
+	return fmt.Sprint(v)
+}
+
+func positive(v any) error {
+	if f, _ := number(v); !(f > 0) {
+		return fmt.Errorf("must be positive, got %v", v)
+	}
+	return nil
+}
+
+func nonNegative(v any) error {
+	if f, _ := number(v); !(f >= 0) {
+		return fmt.Errorf("must not be negative, got %v", v)
+	}
+	return nil
+}
+
+func unitInterval(v any) error {
+	if f, _ := number(v); !(f >= 0 && f <= 1) {
+		return fmt.Errorf("must be in [0,1], got %v", v)
+	}
+	return nil
+}
+
+// timeStep is the range of dt, which the smooth modes use as a fraction of a step
+func timeStep(v any) error {
+	if f, _ := number(v); !(f > 0 && f <= 1) {
+		return fmt.Errorf("must be in (0,1], got %v", v)
+	}
+	return nil
+}
+
+var sigmoid = parsed(func(s string) error { _, err := smoothlife.ParseSigmoid(s); return err })
+
+// parsed checks a string with one of the Parse functions of the library
+func parsed(parse func(s string) error) func(v any) error {
+	return func(v any) error {
+		return parse(v.(string))
+	}
+}
+
+// decodeJSONConfig decodes a JSON object, rejecting duplicate keys and anything
+// after it, and finds the line of every key
+func decodeJSONConfig(data []byte) (map[string]any, map[string]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree map[string]any
+	if err := dec.Decode(&tree); err != nil {
+		var syntax *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntax):
+			return nil, nil, lineError{lineAt(data, syntax.Offset), err}
+		case errors.As(err, &typ):
+			return nil, nil, lineError{lineAt(data, typ.Offset), errors.New("the config must be a JSON object")}
+		}
+		return nil, nil, lineError{lineAt(data, dec.InputOffset()), err}
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, nil, lineError{lineAt(data, dec.InputOffset()), errors.New("unexpected data after the config")}
+	}
+
+	lines := map[string]int{}
+	walker := json.NewDecoder(bytes.NewReader(data))
+	walker.UseNumber()
+	if err := walkJSON(walker, data, "", lines); err != nil {
+		return nil, nil, err
+	}
+	return tree, lines, nil
+}
+
+// walkJSON records the line of every key of the value walker is at
+func walkJSON(walker *json.Decoder, data []byte, prefix string, lines map[string]int) error {
+	tok, err := walker.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for walker.More() {
+			tok, err := walker.Token()
+			if err != nil {
+				return err
+			}
+			key := prefix + tok.(string)
+			line := lineAt(data, walker.InputOffset())
+			if _, ok := lines[key]; ok {
+				return lineError{line, fmt.Errorf("duplicate key %s", key)}
+			}
+			lines[key] = line
+			if err := walkJSON(walker, data, key+".", lines); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for walker.More() {
+			if err := walkJSON(walker, data, prefix, lines); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+	// The closing delimiter
+	_, err = walker.Token()
+	return err
+}
+
+// lineAt returns the line of the byte at offset, counting from 1
+func lineAt(data []byte, offset int64) int {
+	offset = min(offset, int64(len(data)))
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// decodeTOMLConfig decodes a TOML document and finds the line of every key
+func decodeTOMLConfig(data []byte) (map[string]any, map[string]int, error) {
+	var tree map[string]any
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		var parse toml.ParseError
+		if errors.As(err, &parse) {
+			// Drop the position ParseError puts in front of its message
+			prefix := fmt.Sprintf("toml: line %d: ", parse.Position.Line)
+			if parse.LastKey != "" {
+				prefix = fmt.Sprintf("toml: line %d (last key %q): ", parse.Position.Line, parse.LastKey)
+			}
+			return nil, nil, lineError{parse.Position.Line, errors.New(strings.TrimPrefix(parse.Error(), prefix))}
+		}
+		return nil, nil, err
+	}
+	return tree, tomlKeyLines(string(data)), nil
+}
+
+// tomlKeyLines finds the line of every table header and key. It understands the
+// plain documents configs are written as, keys it misses fall back on the line
+// of their table.
+func tomlKeyLines(src string) map[string]int {
+	lines := map[string]int{}
+	table := ""
+	multiline := ""
+	for i, line := range strings.Split(src, "\n") {
+		if multiline != "" {
+			if strings.Count(line, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.LastIndexByte(trimmed, ']')
+			if end < 0 {
+				continue
+			}
+			table = tomlKey(strings.Trim(trimmed[:end], "[]"))
+			lines[table] = i + 1
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		path := tomlKey(key)
+		if table != "" {
+			path = table + "." + path
+		}
+		lines[path] = i + 1
+		for _, quotes := range []string{`"""`, `'''`} {
+			if strings.Count(value, quotes)%2 == 1 {
+				multiline = quotes
+			}
+		}
+	}
+	return lines
+}
+
+// tomlKey normalises a possibly dotted and quoted key
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// parseWithConfig writes a config file and parses args and the config into the
+// simulation and render flags
+func parseWithConfig(t *testing.T, name string, config string, args ...string) (*simulationFlags, *renderFlags, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	simFlags := addSimulationFlags(fs)
+	renderFlags := addRenderFlags(fs)
+	return simFlags, renderFlags, simFlags.parse(append([]string{"-config", path}, args...))
+}
+
+func TestConfigSetsOptions(t *testing.T) {
+	cases := []struct {
+		name   string
+		file   string
+		config string
+	}{
+		{"TOML", "experiment.toml", `
+[grid]
+width = 96
+height = 64
+boundary = "reflect"
+
+[kernel]
+inner_radius = 4
+outer_radius = 12.5
+
+[rule]
+b1 = 0.25
+interval_sigmoid = "cubic"
+
+[time]
+mode = "smooth-relax"
+integrator = "rk4"
+dt = 0.05
+
+[seeding]
+seed = 7
+
+[render]
+colormap = "magma"
+
+[output]
+steps = 3
+`},
+		{"JSON", "experiment.json", `{
+	"grid": {"width": 96, "height": 64, "boundary": "reflect"},
+	"kernel": {"inner_radius": 4, "outer_radius": 12.5},
+	"rule": {"b1": 0.25, "interval_sigmoid": "cubic"},
+	"time": {"mode": "smooth-relax", "integrator": "rk4", "dt": 0.05},
+	"seeding": {"seed": 7},
+	"render": {"colormap": "magma"},
+	"output": {"steps": 3}
+}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			simFlags, renderFlags, err := parseWithConfig(t, tc.file, tc.config, "-height", "80")
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts, err := simFlags.options()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The command line wins over the file
+			if opts.Width != 96 || opts.Height != 80 {
+				t.Errorf("grid is %dx%d; want 96x80", opts.Width, opts.Height)
+			}
+			if opts.InnerRadius != 4 || opts.OuterRadius != 12.5 || opts.Boundary != smoothlife.BoundaryReflect || opts.Seed != 7 {
+				t.Errorf("options %+v do not match the config", opts)
+			}
+			rules := opts.Rule.(smoothlife.BasicRules)
+			if rules.B1 != 0.25 || rules.B2 != 0.365 || rules.IntervalSigmoid != smoothlife.SigmoidCubic {
+				t.Errorf("rules %+v do not match the config", rules)
+			}
+			want := smoothlife.TimeStep{Mode: smoothlife.SmoothRelax, Integrator: smoothlife.RK4, Dt: 0.05}
+			if opts.TimeStep != want {
+				t.Errorf("time step %+v; want %+v", opts.TimeStep, want)
+			}
+			if renderFlags.colormap != "magma" {
+				t.Errorf("colour map %q; want magma", renderFlags.colormap)
+			}
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		file   string
+		config string
+		want   []string
+	}{
+		{"Unknown key", "bad.toml", "[grid]\nwidth = 64\nwidht = 64\n", []string{"bad.toml:3: grid.widht: unknown key"}},
+		{"Unknown section", "bad.toml", "[grid]\nwidth = 64\n\n[kernal]\ninner_radius = 3\n", []string{"bad.toml:4: kernal: unknown section"}},
+		{"Key outside a section", "bad.toml", "width = 64\n", []string{"bad.toml:1: width: unknown key"}},
+		{"Wrong type", "bad.toml", "[grid]\n\nwidth = \"wide\"\n", []string{`bad.toml:3: grid.width: must be an integer, got "wide"`}},
+		{"Fraction for an integer", "bad.json", "{\n  \"grid\": {\n    \"width\": 64.5\n  }\n}", []string{"bad.json:3: grid.width: must be an integer, got 64.5"}},
+		{"Out of range", "bad.toml", "[rule]\nb1 = 1.5\n", []string{"bad.toml:2: rule.b1: must be in [0,1], got 1.5"}},
+		{"Time step too large", "bad.toml", "[time]\nmode = \"smooth-relax\"\ndt = 1.5\n", []string{"bad.toml:3: time.dt: must be in (0,1], got 1.5"}},
+		{"Coupling", "bad.toml", "[grid]\nchannels = 2\ncoupling = 1.5\n", []string{"bad.toml:3: grid.coupling: must be in [0,1], got 1.5"}},
+		{"Boundary value", "bad.toml", "[grid]\nboundary = \"fixed\"\nboundary_value = 2\n", []string{"bad.toml:3: grid.boundary_value: must be in [0,1], got 2"}},
+		{"Radii out of order", "bad.toml", "[kernel]\ninner_radius = 30\nouter_radius = 20\n", []string{"bad.toml:3: kernel.outer_radius: the outer radius 20 must be larger than the inner radius 30"}},
+		{"Inner radius past the default outer", "bad.toml", "[kernel]\ninner_radius = 70\n", []string{"bad.toml:2: kernel.inner_radius: the outer radius 60 must be larger than the inner radius 70"}},
+		{"Unknown name", "bad.toml", "[time]\nmode = \"smooth\"\n", []string{`bad.toml:2: time.mode: smoothlife: unknown time step mode "smooth"`}},
+		{"Bad generator", "bad.toml", "[seeding]\ninit = \"discs:size=3\"\n", []string{"bad.toml:2: seeding.init:"}},
+		{"Every problem", "bad.toml", "[grid]\nwidth = 0\n[kernel]\nouter_radius = -1\n", []string{"bad.toml:2: grid.width: must be positive", "bad.toml:4: kernel.outer_radius: must be positive"}},
+		{"TOML syntax", "bad.toml", "[grid]\nwidth = 64\nheight = 64 64\nchannels = 1\n", []string{"bad.toml:3:"}},
+		{"JSON syntax", "bad.json", "{\n  \"grid\": {\n    \"width\": 64,\n  }\n}", []string{"bad.json:4:"}},
+		{"Duplicate JSON key", "bad.json", "{\"grid\": {\n\"width\": 64,\n\"width\": 32}}", []string{"bad.json:3: duplicate key grid.width"}},
+		{"Not an object", "bad.json", "[1, 2]", []string{"bad.json:1: the config must be a JSON object"}},
+		{"Extension", "bad.yaml", "grid: {}", []string{"must be .json or .toml"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := parseWithConfig(t, tc.file, tc.config)
+			if err == nil {
+				t.Fatal("config was accepted")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	simFlags := addSimulationFlags(fs)
+	addRenderFlags(fs)
+	if err := simFlags.parse([]string{"-config", filepath.Join("..", "..", "configs", "example.toml")}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := simFlags.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := smoothlife.DefaultOptions()
+	if opts.Width != defaults.Width || opts.Rule != defaults.Rule || opts.TimeStep != defaults.TimeStep {
+		t.Errorf("the example config does not hold the defaults: %+v", opts)
+	}
+}
+
+func TestCoupling(t *testing.T) {
+	config := writeCatalogue(t, "coupled.toml", "[grid]\nchannels = 3\ncoupling = 0.4\n")
+	cases := []struct {
+		name string
+		args []string
+		want [2]float64
+	}{
+		{"Default", []string{"-channels", "2"}, [2]float64{0.8, 0.2}},
+		{"Flag", []string{"-channels", "2", "-coupling", "0.5"}, [2]float64{0.5, 0.5}},
+		{"Config", []string{"-config", config}, [2]float64{0.6, 0.2}},
+		{"Flag over config", []string{"-config", config, "-coupling", "0"}, [2]float64{1, 0}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := parseOptions(t, tc.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if own, other := opts.Weights[0][0], opts.Weights[0][1]; math.Abs(own-tc.want[0]) > 1e-12 || math.Abs(other-tc.want[1]) > 1e-12 {
+				t.Errorf("weights %v; want %v of its own channel and %v of another", opts.Weights, tc.want[0], tc.want[1])
+			}
+		})
+	}
+
+	if _, err := parseOptions(t, "-channels", "2", "-coupling", "-0.1"); err == nil || !strings.Contains(err.Error(), "coupling") {
+		t.Errorf("a negative coupling gave %v", err)
+	}
+}
+
+func TestRunFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "small.toml")
+	err := os.WriteFile(config, []byte(`
+[grid]
+width = 48
+height = 32
+
+[kernel]
+inner_radius = 3
+outer_radius = 9
+
+[seeding]
+seed = 3
+
+[output]
+steps = 2
+every = 2
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "frames")
+	if err := run([]string{"-config", config, "-out", out}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"metadata.json", "frame_000000.png", "frame_000002.png"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("missing output: %v", err)
+		}
+	}
+}
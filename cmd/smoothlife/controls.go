@@ -0,0 +1,230 @@
+//go:build viewer
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// defaultRate matches the original pace of a step every sixth tick
+	defaultRate = 10
+	minRate     = 0.5
+	maxRate     = 480
+	// messageTicks is how long a status message stays on screen
+	messageTicks = 120
+)
+
+// binding is a key and what it does, listed in the help overlay
+type binding struct {
+	key    ebiten.Key
+	label  string
+	help   string
+	action func(g *Game)
+}
+
+var bindings = []binding{
+	{ebiten.KeySpace, "space", "pause or resume", func(g *Game) {
+		g.paused = !g.paused
+		g.loop.setPaused(g.paused)
+	}},
+	{ebiten.KeyN, "n", "advance one step while paused", func(g *Game) {
+		if g.paused {
+			g.loop.stepOnce()
+		}
+	}},
+	{ebiten.KeyArrowUp, "up", "double the steps per second", func(g *Game) { g.setRate(g.rate * 2) }},
+	{ebiten.KeyArrowDown, "down", "halve the steps per second", func(g *Game) { g.setRate(g.rate / 2) }},
+	{ebiten.KeyF, "f", "run as fast as possible or at the set rate", (*Game).toggleFast},
+	{ebiten.KeyC, "c", "clear the field", func(g *Game) { g.loop.do(func() { g.sim.Clear() }) }},
+	{ebiten.KeyR, "r", "reseed and start again", (*Game).reseed},
+	{ebiten.KeyS, "s", "save a screenshot", (*Game).screenshot},
+	{ebiten.KeyM, "m", "next colour map", (*Game).cycleColormap},
+	{ebiten.KeyP, "p", "switch to the next preset, keeping the field", (*Game).cyclePreset},
+	{ebiten.KeyV, "v", "colour by field, rate, densities or motion", (*Game).cycleRenderMode},
+	{ebiten.KeyL, "l", "inspect the next layer: m, n, aliveness, thresholds, delta S, kernels", func(g *Game) {
+		g.loop.do(g.inspector.next)
+		g.tooltip = true
+	}},
+	{ebiten.KeyTab, "tab", "toggle the parameter sliders", func(g *Game) { g.panel.visible = !g.panel.visible }},
+	{ebiten.KeyU, "u", "undo the last slider edit", func(g *Game) { g.panel.undo(g) }},
+	{ebiten.KeyT, "t", "toggle the value tooltip", func(g *Game) { g.tooltip = !g.tooltip }},
+	{ebiten.KeyI, "i", "toggle the statistics overlay", func(g *Game) { g.hud.visible = !g.hud.visible }},
+	{ebiten.KeyH, "h", "toggle this list of controls", func(g *Game) { g.showHelp = !g.showHelp }},
+}
+
+// mouseHelp lists the painting controls, see painter
+var mouseHelp = [][2]string{
+	{"left drag", "paint life"},
+	{"right drag", "erase"},
+	{"wheel [ ]", "brush radius"},
+	{"shift+wheel - =", "brush intensity"},
+}
+
+// bindingsMarkdown lists the bindings and mouse controls as the table in README.md
+func bindingsMarkdown() string {
+	var table strings.Builder
+	table.WriteString("| Control | Action |\n| --- | --- |\n")
+	for _, b := range bindings {
+		fmt.Fprintf(&table, "| `%s` | %s |\n", b.label, b.help)
+	}
+	for _, m := range mouseHelp {
+		fmt.Fprintf(&table, "| `%s` | %s |\n", m[0], m[1])
+	}
+	return table.String()
+}
+
+// handleKeys runs the action of every binding pressed this tick
+func (g *Game) handleKeys() {
+	if g.messageTimer > 0 {
+		g.messageTimer--
+	}
+	for _, b := range bindings {
+		if inpututil.IsKeyJustPressed(b.key) {
+			b.action(g)
+		}
+	}
+}
+
+func (g *Game) setRate(rate float64) {
+	g.rate = smoothlife.Clamp(rate, minRate, maxRate)
+	g.fast = false
+	g.loop.setRate(g.rate)
+	g.showMessage(fmt.Sprintf("%g steps per second", g.rate))
+}
+
+func (g *Game) toggleFast() {
+	g.fast = !g.fast
+	g.loop.setRate(g.targetRate())
+	if g.fast {
+		g.showMessage("as fast as possible")
+	} else {
+		g.showMessage(fmt.Sprintf("%g steps per second", g.rate))
+	}
+}
+
+// targetRate is the rate the loop runs at, 0 being as fast as possible
+func (g *Game) targetRate() float64 {
+	if g.fast {
+		return 0
+	}
+	return g.rate
+}
+
+// reseed restarts the random source from a new seed and draws a fresh initial state
+func (g *Game) reseed() {
+	seed := time.Now().UnixNano()
+	g.loop.do(func() {
+		g.sim.Reseed(seed)
+		g.sim.Clear()
+		if err := g.sim.Generate(g.generator); err != nil {
+			g.tuner.notify(err.Error())
+		}
+	})
+	logger.Printf("reseeded with %d", seed)
+	g.showMessage(fmt.Sprintf("seed %d", seed))
+}
+
+// screenshot writes the frame on screen to a PNG in the working directory
+func (g *Game) screenshot() {
+	path := filepath.Join(".", fmt.Sprintf("smoothlife_%s_step%06d.png", time.Now().Format("20060102_150405"), g.frame.step))
+	if err := writePNG(path, g.frame.img); err != nil {
+		logger.Printf("saving screenshot: %v", err)
+		g.showMessage("screenshot failed, see app.log")
+		return
+	}
+	logger.Printf("saved %s", path)
+	g.showMessage("saved " + path)
+}
+
+// cycleColormap switches to the next built in colour map
+func (g *Game) cycleColormap() {
+	names := smoothlife.ColormapNames()
+	next := names[0]
+	for i, name := range names {
+		if name == g.colormap {
+			next = names[(i+1)%len(names)]
+		}
+	}
+	g.colormap = next
+	cm, _ := smoothlife.ParseColormap(next)
+	g.loop.do(func() { g.renderer.Colormap = cm })
+	g.showMessage("colour map " + next)
+}
+
+// cyclePreset applies the next preset to the running simulation, keeping its field
+func (g *Game) cyclePreset() {
+	names := smoothlife.PresetNames()
+	next := names[0]
+	for i, name := range names {
+		if name == g.preset {
+			next = names[(i+1)%len(names)]
+		}
+	}
+	g.preset = next
+	preset, err := smoothlife.LookupPreset(next)
+	if err != nil {
+		logger.Printf("preset: %v", err)
+		return
+	}
+	g.tuner.change(func(opts *smoothlife.Options) { *opts = preset.Apply(*opts) })
+	g.showMessage("preset " + next)
+}
+
+var renderModes = []smoothlife.RenderMode{
+	smoothlife.RenderField,
+	smoothlife.RenderRate,
+	smoothlife.RenderDensities,
+	smoothlife.RenderMotion,
+}
+
+func (g *Game) cycleRenderMode() {
+	for i, mode := range renderModes {
+		if mode == g.renderMode {
+			g.renderMode = renderModes[(i+1)%len(renderModes)]
+			break
+		}
+	}
+	mode := g.renderMode
+	g.loop.do(func() { g.renderer.Mode = mode })
+	g.showMessage("colouring by " + mode.String())
+}
+
+func (g *Game) showMessage(message string) {
+	g.message = message
+	g.messageTimer = messageTicks
+}
+
+// drawOverlay draws the status message and, when toggled on, the help
+func (g *Game) drawOverlay(screen *ebiten.Image) {
+	if g.messageTimer > 0 {
+		ebitenutil.DebugPrintAt(screen, g.message, 4, g.height-20)
+	}
+	if g.paused {
+		ebitenutil.DebugPrintAt(screen, "paused", g.width-48, 4)
+	}
+	if !g.showHelp {
+		return
+	}
+	var help strings.Builder
+	for _, b := range bindings {
+		fmt.Fprintf(&help, "%-16s %s\n", b.label, b.help)
+	}
+	for _, m := range mouseHelp {
+		fmt.Fprintf(&help, "%-16s %s\n", m[0], m[1])
+	}
+	lines := len(bindings) + len(mouseHelp)
+	vector.DrawFilledRect(screen, 0, 0, 320, float32(16*lines+8), color.NRGBA{A: 0xc0}, false)
+	ebitenutil.DebugPrintAt(screen, help.String(), 4, 4)
+}
@@ -0,0 +1,25 @@
+//go:build viewer
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadmeBindings(t *testing.T) {
+	readme, err := os.ReadFile(filepath.Join("..", "..", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rest, found := strings.Cut(string(readme), "<!-- bindings: generated by bindingsMarkdown in cmd/smoothlife/controls.go -->\n")
+	table, _, ended := strings.Cut(rest, "<!-- end bindings -->")
+	if !found || !ended {
+		t.Fatal("README.md has no bindings table")
+	}
+	if want := bindingsMarkdown(); table != want {
+		t.Errorf("README.md lists the controls as\n%s\nwant\n%s", table, want)
+	}
+}
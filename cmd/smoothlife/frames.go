@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"strings"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// renderFlags holds the flags choosing how frames are coloured
+type renderFlags struct {
+	colormap string
+	mode     string
+	gain     float64
+}
+
+func addRenderFlags(fs *flag.FlagSet) *renderFlags {
+	f := &renderFlags{}
+	fs.StringVar(&f.colormap, "colormap", "grayscale", "colour map: "+strings.Join(smoothlife.ColormapNames(), ", ")+", or a gradient such as gradient:#000000,#ff8800,#ffffff")
+	fs.StringVar(&f.mode, "render", "field", "what to colour by: field, rate (of change), densities (m in red, n in blue) or motion")
+	fs.Float64Var(&f.gain, "gain", 10, "scale of rates of change and speeds before they are coloured")
+	return f
+}
+
+func (f *renderFlags) renderer() (*smoothlife.Renderer, error) {
+	cm, err := smoothlife.ParseColormap(f.colormap)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := smoothlife.ParseRenderMode(f.mode)
+	if err != nil {
+		return nil, err
+	}
+	return &smoothlife.Renderer{Mode: mode, Colormap: cm, Gain: f.gain}, nil
+}
+
+// paletted converts a frame for GIF encoding. Frames drawn from a colour map keep
+// every colour of it, others are dithered to the Plan 9 palette.
+func paletted(img *image.RGBA, colors color.Palette) *image.Paletted {
+	if colors == nil {
+		out := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(out, img.Bounds(), img, image.Point{})
+		return out
+	}
+	index := make(map[color.RGBA]uint8, len(colors))
+	for i := len(colors) - 1; i >= 0; i-- {
+		index[colors[i].(color.RGBA)] = uint8(i)
+	}
+	out := image.NewPaletted(img.Bounds(), colors)
+	for i := range out.Pix {
+		p := img.Pix[i*4 : i*4+4]
+		out.Pix[i] = index[color.RGBA{R: p[0], G: p[1], B: p[2], A: p[3]}]
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+//go:build viewer
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// hudHistory is the number of steps the mass sparkline spans
+	hudHistory = 300
+	hudWidth   = 300
+	hudLine    = 16
+	// sparklineHeight is the height of the mass sparkline in pixels
+	sparklineHeight = 40
+)
+
+var sparklineColor = color.NRGBA{R: 0x6e, G: 0xce, B: 0x58, A: 0xff}
+
+// hud shows statistics of the simulation over the field: the step, the measured
+// steps per second, the mass, the fraction of live cells, the rule and a sparkline
+// of the mass over the last steps. The history is recorded on the loop's goroutine
+// and reaches the window through frames.
+type hud struct {
+	visible bool
+
+	// mass is a ring buffer of the mass after each step, next is where the next
+	// value goes
+	mass  []float64
+	next  int
+	count int
+}
+
+func newHUD(visible bool) *hud {
+	return &hud{visible: visible, mass: make([]float64, hudHistory)}
+}
+
+// record notes a step of sim
+func (h *hud) record(sim *smoothlife.Simulation) {
+	h.mass[h.next] = sim.Stats(0).Mass
+	h.next = (h.next + 1) % len(h.mass)
+	h.count = min(h.count+1, len(h.mass))
+}
+
+// history appends the recorded masses to dst, oldest first
+func (h *hud) history(dst []float64) []float64 {
+	for i := 0; i < h.count; i++ {
+		dst = append(dst, h.mass[(h.next-h.count+i+len(h.mass))%len(h.mass)])
+	}
+	return dst
+}
+
+func (h *hud) draw(screen *ebiten.Image, f *frame) {
+	if !h.visible {
+		return
+	}
+	text := fmt.Sprintf("step %d\n%.1f steps/s\nmass %.4f\nalive %.1f%%\n%s\n%s",
+		f.step, f.stepRate, f.stats.Mass, 100*f.stats.Alive, ruleSummary(f.options.Rule), timeStepSummary(f.options.TimeStep))
+	lines := 6
+	top := float32(lines*hudLine + 8)
+	vector.DrawFilledRect(screen, 0, 0, hudWidth, top+sparklineHeight+8, color.NRGBA{A: 0xa0}, false)
+	ebitenutil.DebugPrintAt(screen, text, 4, 4)
+	drawSparkline(screen, f.mass, 4, top, hudWidth-8, sparklineHeight)
+}
+
+// drawSparkline plots the masses, oldest first, scaled to their range
+func drawSparkline(screen *ebiten.Image, mass []float64, x float32, y float32, width float32, height float32) {
+	if len(mass) < 2 {
+		return
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, m := range mass {
+		low, high = math.Min(low, m), math.Max(high, m)
+	}
+	span := high - low
+	if span == 0 {
+		span = 1
+	}
+	point := func(i int) (float32, float32) {
+		return x + width*float32(i)/float32(hudHistory-1), y + height*float32(1-(mass[i]-low)/span)
+	}
+	for i := 1; i < len(mass); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		vector.StrokeLine(screen, x0, y0, x1, y1, 1, sparklineColor, true)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.4f", high), int(x+width)-48, int(y)-4)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.4f", low), int(x+width)-48, int(y+height)-12)
+}
+
+// ruleSummary lists the parameters of the rules the viewer knows about
+func ruleSummary(rule smoothlife.Rule) string {
+	switch r := rule.(type) {
+	case smoothlife.BasicRules:
+		return fmt.Sprintf("B %.3f-%.3f D %.3f-%.3f N %.3f M %.3f", r.B1, r.B2, r.D1, r.D2, r.N, r.M)
+	case *smoothlife.BasicRules:
+		return ruleSummary(*r)
+	case smoothlife.LeniaRules:
+		return fmt.Sprintf("lenia mu %.3f sigma %.4f T %g %v", r.Mu, r.Sigma, r.T, r.Growth)
+	case *smoothlife.LeniaRules:
+		return ruleSummary(*r)
+	default:
+		return fmt.Sprintf("%T", rule)
+	}
+}
+
+func timeStepSummary(ts smoothlife.TimeStep) string {
+	if ts.Mode == smoothlife.Discrete {
+		return "discrete"
+	}
+	return fmt.Sprintf("%v %v dt %g", ts.Mode, ts.Integrator, ts.Dt)
+}
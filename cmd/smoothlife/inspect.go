@@ -0,0 +1,146 @@
+//go:build viewer
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"gonum.org/v1/gonum/mat"
+)
+
+// inspectorLayers is the order l cycles through
+var inspectorLayers = []smoothlife.Layer{
+	smoothlife.LayerField,
+	smoothlife.LayerM,
+	smoothlife.LayerN,
+	smoothlife.LayerAliveness,
+	smoothlife.LayerThreshold1,
+	smoothlife.LayerThreshold2,
+	smoothlife.LayerDeltaS,
+	smoothlife.LayerInnerKernel,
+	smoothlife.LayerAnnulusKernel,
+}
+
+// inspector shows a layer of the update of the first channel in place of the field,
+// each on its own colour scale, with a tooltip giving the values under the cursor.
+// It measures on the loop's goroutine and draws from frames.
+type inspector struct {
+	layer  int
+	buffer *mat.Dense
+	// low and high are the ends of the colour scale of the current layer
+	low  float64
+	high float64
+	err  error
+}
+
+func newInspector(width int, height int) *inspector {
+	return &inspector{buffer: mat.NewDense(height, width, nil)}
+}
+
+func (in *inspector) current() smoothlife.Layer {
+	return inspectorLayers[in.layer]
+}
+
+// active reports whether a layer other than the field is shown
+func (in *inspector) active() bool {
+	return in.current() != smoothlife.LayerField
+}
+
+func (in *inspector) next() {
+	in.layer = (in.layer + 1) % len(inspectorLayers)
+}
+
+// render measures the current layer and draws it into img
+func (in *inspector) render(img *image.RGBA, sim *smoothlife.Simulation) {
+	layer := in.current()
+	if in.err = sim.Inspect(layer, 0, in.buffer); in.err != nil {
+		in.buffer.Zero()
+	}
+	cm := smoothlife.Viridis
+	in.low, in.high = 0, 1
+	switch layer {
+	case smoothlife.LayerThreshold1, smoothlife.LayerThreshold2:
+		cm = smoothlife.Magma
+		in.low, in.high = mat.Min(in.buffer), mat.Max(in.buffer)
+	case smoothlife.LayerDeltaS:
+		// Signed, no change sits in the dark middle of the cyclic map
+		cm = smoothlife.Twilight
+		extent := math.Max(math.Abs(mat.Min(in.buffer)), math.Abs(mat.Max(in.buffer)))
+		in.low, in.high = -extent, extent
+	case smoothlife.LayerInnerKernel, smoothlife.LayerAnnulusKernel:
+		cm = smoothlife.Magma
+		in.high = mat.Max(in.buffer)
+	}
+	span := in.high - in.low
+	if span == 0 {
+		span = 1
+	}
+
+	rows, cols := in.buffer.Dims()
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			c := cm.At((in.buffer.At(y, x) - in.low) / span)
+			index := y*img.Stride + x*4
+			img.Pix[index], img.Pix[index+1], img.Pix[index+2], img.Pix[index+3] = c.R, c.G, c.B, 0xff
+		}
+	}
+}
+
+// capture copies what the label and tooltip need into f
+func (in *inspector) capture(f *frame, sim *smoothlife.Simulation) {
+	f.field = copyInto(f.field, sim.Field())
+	f.inspected = ""
+	if !in.active() {
+		return
+	}
+	f.inspected = in.current().String()
+	f.layer = copyInto(f.layer, in.buffer)
+	f.low, f.high, f.err = in.low, in.high, in.err
+}
+
+// copyInto copies src into dst, allocating dst when it is missing
+func copyInto(dst *mat.Dense, src *mat.Dense) *mat.Dense {
+	if dst == nil {
+		return mat.DenseCopyOf(src)
+	}
+	dst.Copy(src)
+	return dst
+}
+
+// draw names the layer and its scale, and shows the tooltip
+func (in *inspector) draw(screen *ebiten.Image, f *frame, tooltip bool) {
+	rows, cols := f.field.Dims()
+	if f.inspected != "" {
+		label := fmt.Sprintf("%s [%.4g, %.4g]", f.inspected, f.low, f.high)
+		if f.err != nil {
+			label = f.err.Error()
+		}
+		ebitenutil.DebugPrintAt(screen, label, 4, rows-36)
+	}
+	if !tooltip {
+		return
+	}
+	x, y := ebiten.CursorPosition()
+	if x < 0 || y < 0 || x >= cols || y >= rows {
+		return
+	}
+	text := fmt.Sprintf("(%d, %d)\nfield %.6g", x, y, f.field.At(y, x))
+	if f.inspected != "" && f.err == nil {
+		text += fmt.Sprintf("\n%s %.6g", f.inspected, f.layer.At(y, x))
+	}
+	// Keep the tooltip inside the window
+	tx, ty := x+12, y+12
+	if tx > cols-160 {
+		tx = x - 160
+	}
+	if ty > rows-48 {
+		ty = y - 48
+	}
+	ebitenutil.DebugPrintAt(screen, text, tx, ty)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"image"
+	"sync"
+	"time"
+
+	"SmoothLifeGo/smoothlife"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// frameInterval caps how often the loop renders while it runs as fast as it can
+	frameInterval = time.Second / 60
+	// maxLag is how far the loop may fall behind its target rate before it gives up
+	// catching up
+	maxLag = time.Second
+	// commandQueue is the number of commands that can wait between two steps
+	commandQueue = 256
+)
+
+// frame is a finished picture of the simulation, along with what the overlays show
+// about it, so that the window never has to touch the simulation itself
+type frame struct {
+	img      *image.RGBA
+	step     uint64
+	stepRate float64
+
+	options smoothlife.Options
+	stats   smoothlife.Stats
+	// mass is the mass after each of the last steps, oldest first
+	mass []float64
+	// field is a copy of the first channel. Unless inspected is empty, layer is a copy
+	// of the inspected layer, with low and high the ends of its colour scale, or err
+	// says why it could not be measured.
+	field     *mat.Dense
+	inspected string
+	layer     *mat.Dense
+	low       float64
+	high      float64
+	err       error
+}
+
+// loop steps a simulation on its own goroutine, at a target rate or as fast as it
+// can, and publishes frames through a triple buffer: the loop renders into back,
+// swaps it with ready, and frame swaps ready with the one the window shows. Only
+// the loop's goroutine touches the simulation, everything else goes through do.
+type loop struct {
+	sim *smoothlife.Simulation
+	// render fills a frame, observe is told of every step. Both run on the loop's
+	// goroutine.
+	render  func(f *frame)
+	observe func()
+
+	commands chan func()
+	quit     chan struct{}
+	done     chan struct{}
+
+	// Owned by the loop's goroutine. A rate of 0 runs as fast as possible.
+	paused   bool
+	rate     float64
+	due      time.Time
+	dirty    bool
+	rendered time.Time
+
+	// Steps per second are measured over windows of a second
+	windowStart time.Time
+	windowSteps int
+	stepRate    float64
+
+	mu    sync.Mutex
+	back  *frame
+	ready *frame
+	shown *frame
+	fresh bool
+}
+
+func newLoop(sim *smoothlife.Simulation, rate float64, render func(f *frame), observe func()) *loop {
+	opts := sim.Options()
+	newFrame := func() *frame {
+		return &frame{img: image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))}
+	}
+	return &loop{
+		sim:      sim,
+		render:   render,
+		observe:  observe,
+		commands: make(chan func(), commandQueue),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		rate:     rate,
+		dirty:    true,
+		back:     newFrame(),
+		ready:    newFrame(),
+		shown:    newFrame(),
+	}
+}
+
+// start publishes the first frame and runs the loop until stop
+func (l *loop) start() {
+	l.windowStart = time.Now()
+	l.due = l.windowStart
+	l.publish()
+	l.frame()
+	go l.run()
+}
+
+// stop ends the loop and waits for the step in progress to finish
+func (l *loop) stop() {
+	close(l.quit)
+	<-l.done
+}
+
+// do queues f to run on the loop's goroutine between two steps. The frame after
+// it reflects its changes.
+func (l *loop) do(f func()) {
+	select {
+	case l.commands <- f:
+	case <-l.done:
+	}
+}
+
+// setPaused and setRate take effect before the next step
+func (l *loop) setPaused(paused bool) {
+	l.do(func() {
+		l.paused = paused
+		l.due = time.Now()
+		l.windowStart = l.due
+		l.windowSteps = 0
+	})
+}
+
+func (l *loop) setRate(rate float64) {
+	l.do(func() {
+		l.rate = rate
+		l.due = time.Now()
+	})
+}
+
+// stepOnce advances a single step, for use while paused
+func (l *loop) stepOnce() {
+	l.do(l.step)
+}
+
+// frame returns the latest published frame, which stays the caller's until the
+// next call
+func (l *loop) frame() *frame {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fresh {
+		l.shown, l.ready = l.ready, l.shown
+		l.fresh = false
+	}
+	return l.shown
+}
+
+func (l *loop) run() {
+	defer close(l.done)
+	// A stale tick from an earlier wait only makes the loop check the time again
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		if !l.drain() {
+			return
+		}
+		if l.paused {
+			l.publish()
+			if !l.wait(nil) {
+				return
+			}
+			continue
+		}
+		if l.rate > 0 {
+			now := time.Now()
+			if delay := l.due.Sub(now); delay > 0 {
+				l.publish()
+				timer.Reset(delay)
+				if !l.wait(timer.C) {
+					return
+				}
+				continue
+			}
+			l.due = l.due.Add(time.Duration(float64(time.Second) / l.rate))
+			if now.Sub(l.due) > maxLag {
+				l.due = now
+			}
+		}
+		l.step()
+		if time.Since(l.rendered) >= frameInterval {
+			l.publish()
+		}
+	}
+}
+
+// drain runs the queued commands without blocking and reports whether to go on
+func (l *loop) drain() bool {
+	for {
+		select {
+		case <-l.quit:
+			return false
+		case f := <-l.commands:
+			f()
+			l.dirty = true
+		default:
+			return true
+		}
+	}
+}
+
+// wait blocks until a command arrives, which it runs, or c fires, and reports
+// whether to go on
+func (l *loop) wait(c <-chan time.Time) bool {
+	select {
+	case <-l.quit:
+		return false
+	case f := <-l.commands:
+		f()
+		l.dirty = true
+	case <-c:
+	}
+	return true
+}
+
+func (l *loop) step() {
+	l.sim.Step()
+	if l.observe != nil {
+		l.observe()
+	}
+	l.dirty = true
+	l.windowSteps++
+	if elapsed := time.Since(l.windowStart); elapsed >= time.Second {
+		l.stepRate = float64(l.windowSteps) / elapsed.Seconds()
+		l.windowStart = time.Now()
+		l.windowSteps = 0
+	}
+}
+
+// publish renders a frame if anything changed since the last one and hands it over
+func (l *loop) publish() {
+	if !l.dirty {
+		return
+	}
+	if l.paused {
+		l.stepRate = 0
+	}
+	l.back.step = l.sim.StepCount()
+	l.back.stepRate = l.stepRate
+	if l.render != nil {
+		l.render(l.back)
+	}
+	l.mu.Lock()
+	l.back, l.ready = l.ready, l.back
+	l.fresh = true
+	l.mu.Unlock()
+	l.dirty = false
+	l.rendered = time.Now()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+func loopSimulation(t *testing.T) *smoothlife.Simulation {
+	t.Helper()
+	opts := smoothlife.DefaultOptions()
+	opts.Width, opts.Height = 48, 32
+	opts.InnerRadius, opts.OuterRadius = 3, 9
+	opts.Seed = 1
+	sim, err := smoothlife.ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.AddSpeckles()
+	return sim
+}
+
+// waitForFrame polls the published frames until ok accepts one
+func waitForFrame(t *testing.T, l *loop, ok func(f *frame) bool) *frame {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if f := l.frame(); ok(f) {
+			return f
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no matching frame was published")
+	return nil
+}
+
+func TestLoopRunsAsFastAsPossible(t *testing.T) {
+	sim := loopSimulation(t)
+	observed := 0
+	l := newLoop(sim, 0, func(f *frame) { f.stats = sim.Stats(0) }, func() { observed++ })
+	l.start()
+	waitForFrame(t, l, func(f *frame) bool { return f.step >= 5 })
+	l.stop()
+	if uint64(observed) != sim.StepCount() {
+		t.Errorf("observed %d steps; want %d", observed, sim.StepCount())
+	}
+}
+
+func TestLoopKeepsToItsRate(t *testing.T) {
+	sim := loopSimulation(t)
+	l := newLoop(sim, 10, nil, nil)
+	l.start()
+	time.Sleep(350 * time.Millisecond)
+	l.stop()
+	// A step at once and then one every 100ms
+	if steps := sim.StepCount(); steps > 5 {
+		t.Errorf("stepped %d times in 350ms at 10 steps per second", steps)
+	}
+}
+
+func TestLoopCommandsWhilePaused(t *testing.T) {
+	sim := loopSimulation(t)
+	l := newLoop(sim, 0, func(f *frame) { f.stats = sim.Stats(0) }, nil)
+	l.setPaused(true)
+	l.start()
+	defer l.stop()
+
+	l.stepOnce()
+	waitForFrame(t, l, func(f *frame) bool { return f.step == 1 })
+	l.do(sim.Clear)
+	f := waitForFrame(t, l, func(f *frame) bool { return f.stats.Mass == 0 })
+	if f.step != 1 {
+		t.Errorf("paused loop reached step %d; want 1", f.step)
+	}
+
+	l.setPaused(false)
+	waitForFrame(t, l, func(f *frame) bool { return f.step > 1 })
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const usage = `usage: smoothlife [command] [flags]
+
+commands:
+  view    open the simulation in a window (the default, built with -tags viewer)
+  run     simulate without a display and write frames to disk
+
+Run "smoothlife <command> -h" for the flags of a command.
+`
+
+func main() {
+	command, args := "view", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "view":
+		err = view(args)
+	case "run":
+		err = run(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
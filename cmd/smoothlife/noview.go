@@ -0,0 +1,13 @@
+//go:build !viewer
+
+package main
+
+import (
+	"errors"
+)
+
+// view is unavailable unless built with the viewer tag, which brings in Ebiten and
+// its X11 and OpenGL requirements
+func view(args []string) error {
+	return errors.New("this build has no viewer, rebuild with -tags viewer")
+}
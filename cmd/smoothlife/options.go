@@ -0,0 +1,269 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// simulationFlags holds the flags shared by every command that builds a simulation
+type simulationFlags struct {
+	fs            *flag.FlagSet
+	width         int
+	height        int
+	innerRadius   float64
+	outerRadius   float64
+	logRes        float64
+	preset        string
+	catalogues    catalogueFlag
+	rules         smoothlife.BasicRules
+	sigmoids      [3]string
+	lenia         bool
+	timeStep      string
+	integrator    string
+	dt            float64
+	channels      int
+	coupling      float64
+	boundary      string
+	boundaryValue float64
+	seed          int64
+	init          string
+
+	image          string
+	imageFit       string
+	imageFilter    string
+	imageInvert    bool
+	imageThreshold float64
+
+	config string
+	// given holds the flags of the command line, as opposed to those the config set
+	given map[string]bool
+}
+
+// presetFlags are the flags a -preset sets, which the config file cannot override
+var presetFlags = map[string]bool{
+	"inner": true, "outer": true,
+	"b1": true, "b2": true, "d1": true, "d2": true, "n": true, "m": true,
+	"alive-sigmoid": true, "interval-sigmoid": true, "mix-sigmoid": true,
+	"time-step": true, "integrator": true, "dt": true,
+}
+
+func addSimulationFlags(fs *flag.FlagSet) *simulationFlags {
+	defaults := smoothlife.DefaultOptions()
+	rules := defaults.Rule.(smoothlife.BasicRules)
+	f := &simulationFlags{fs: fs}
+	fs.IntVar(&f.width, "width", defaults.Width, "grid width in cells")
+	fs.IntVar(&f.height, "height", defaults.Height, "grid height in cells")
+	fs.Float64Var(&f.innerRadius, "inner", defaults.InnerRadius, "inner (cell) radius")
+	fs.Float64Var(&f.outerRadius, "outer", defaults.OuterRadius, "outer (neighbourhood) radius")
+	fs.Float64Var(&f.logRes, "logres", defaults.LogRes, "kernel edge sharpness, 0 picks one from the grid size")
+	fs.StringVar(&f.preset, "preset", "", "named radii, rule and time step to start from, flags given on the command line override them but keys of the -config do not; one of "+strings.Join(smoothlife.PresetNames(), ", "))
+	fs.Var(&f.catalogues, "catalogue", "JSON or TOML file of presets to add to -preset, may be repeated")
+	fs.Float64Var(&f.rules.B1, "b1", rules.B1, "lower birth threshold")
+	fs.Float64Var(&f.rules.B2, "b2", rules.B2, "upper birth threshold")
+	fs.Float64Var(&f.rules.D1, "d1", rules.D1, "lower survival threshold")
+	fs.Float64Var(&f.rules.D2, "d2", rules.D2, "upper survival threshold")
+	fs.Float64Var(&f.rules.N, "n", rules.N, "transition width of the neighbourhood interval")
+	fs.Float64Var(&f.rules.M, "m", rules.M, "transition width of the aliveness test")
+	sigmoids := "default, hard, linear, logistic, cubic or sine"
+	fs.StringVar(&f.sigmoids[0], "alive-sigmoid", "default", "shape of the aliveness test on m: "+sigmoids)
+	fs.StringVar(&f.sigmoids[1], "interval-sigmoid", "default", "shape of the interval test on n: "+sigmoids)
+	fs.StringVar(&f.sigmoids[2], "mix-sigmoid", "default", "shape of the mixing of the birth and survival intervals: "+sigmoids)
+	fs.BoolVar(&f.lenia, "lenia", false, "run Lenia (Orbium parameters) instead of SmoothLife")
+	fs.IntVar(&f.channels, "channels", 1, "number of coupled channels, up to three are drawn as RGB")
+	fs.Float64Var(&f.coupling, "coupling", defaultCoupling, "share of each channel's densities taken from the other channels, in [0,1]")
+	fs.StringVar(&f.timeStep, "time-step", defaults.TimeStep.Mode.String(), "how the rule updates the field: discrete, smooth-signed, smooth-relax, smooth-signed-inner or smooth-relax-inner")
+	fs.StringVar(&f.integrator, "integrator", defaults.TimeStep.Integrator.String(), "integrator of the smooth time steps: euler or rk4")
+	fs.Float64Var(&f.dt, "dt", defaults.TimeStep.Dt, "step size of the smooth time steps")
+	fs.StringVar(&f.boundary, "boundary", "periodic", "edge condition: periodic, zero, reflect or fixed")
+	fs.Float64Var(&f.boundaryValue, "boundary-value", 0, "value of the cells beyond a fixed boundary")
+	fs.Int64Var(&f.seed, "seed", 0, "seed of every random choice, 0 picks one from the clock")
+	fs.StringVar(&f.init, "init", "speckles", "initial state, a generator name with optional parameters such as discs:count=40,radius=6; one of "+strings.Join(smoothlife.GeneratorNames(), ", "))
+	fs.StringVar(&f.image, "image", "", "PNG, JPEG or GIF image to start from instead of -init")
+	fs.StringVar(&f.imageFit, "image-fit", "cover", "how the image meets the grid: stretch, cover, contain or crop")
+	fs.StringVar(&f.imageFilter, "image-filter", "bilinear", "resampling filter: nearest, box, bilinear or bicubic")
+	fs.BoolVar(&f.imageInvert, "image-invert", false, "bring dark pixels to life instead of light ones")
+	fs.Float64Var(&f.imageThreshold, "image-threshold", 0, "when positive, cells at or above it become 1 and the rest 0")
+	fs.StringVar(&f.config, "config", "", "JSON or TOML file of settings, flags given on the command line override its keys")
+	return f
+}
+
+// parse parses the command line and then the -config file, whose keys set every
+// flag not given on the command line, and registers the presets of the catalogues
+func (f *simulationFlags) parse(args []string) error {
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+	f.given = map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { f.given[fl.Name] = true })
+	if f.config != "" {
+		if err := loadConfig(f.config, f.fs); err != nil {
+			return err
+		}
+	}
+	for _, path := range f.catalogues {
+		if err := loadCatalogue(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// options builds simulation options from the defaults of the chosen engine, the
+// -preset, the keys of the config file that the preset does not set, and the flags
+// given on the command line, each overriding the ones before
+func (f *simulationFlags) options() (smoothlife.Options, error) {
+	opts := smoothlife.DefaultOptions()
+	if f.lenia {
+		opts = smoothlife.LeniaOptions()
+	}
+	if f.preset != "" {
+		preset, err := smoothlife.LookupPreset(f.preset)
+		if err != nil {
+			return opts, fmt.Errorf("%w, choose one of %s", err, strings.Join(smoothlife.PresetNames(), ", "))
+		}
+		opts = preset.Apply(opts)
+	}
+	mode, err := smoothlife.ParseTimeStepMode(f.timeStep)
+	if err != nil {
+		return opts, err
+	}
+	integrator, err := smoothlife.ParseIntegrator(f.integrator)
+	if err != nil {
+		return opts, err
+	}
+	var sigmoids [3]smoothlife.Sigmoid
+	for i := range sigmoids {
+		if sigmoids[i], err = smoothlife.ParseSigmoid(f.sigmoids[i]); err != nil {
+			return opts, err
+		}
+	}
+	// Rule flags change their own parameter of the preset's rule, or of the
+	// SmoothLife defaults when running Lenia
+	rules, ok := opts.Rule.(smoothlife.BasicRules)
+	if !ok {
+		rules = smoothlife.DefaultOptions().Rule.(smoothlife.BasicRules)
+	}
+	ruleSet := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if f.preset != "" && presetFlags[fl.Name] && !f.given[fl.Name] {
+			return
+		}
+		switch fl.Name {
+		case "width":
+			opts.Width = f.width
+		case "height":
+			opts.Height = f.height
+		case "inner":
+			opts.InnerRadius = f.innerRadius
+		case "outer":
+			opts.OuterRadius = f.outerRadius
+		case "logres":
+			opts.LogRes = f.logRes
+		case "b1":
+			rules.B1, ruleSet = f.rules.B1, true
+		case "b2":
+			rules.B2, ruleSet = f.rules.B2, true
+		case "d1":
+			rules.D1, ruleSet = f.rules.D1, true
+		case "d2":
+			rules.D2, ruleSet = f.rules.D2, true
+		case "n":
+			rules.N, ruleSet = f.rules.N, true
+		case "m":
+			rules.M, ruleSet = f.rules.M, true
+		case "alive-sigmoid":
+			rules.AliveSigmoid, ruleSet = sigmoids[0], true
+		case "interval-sigmoid":
+			rules.IntervalSigmoid, ruleSet = sigmoids[1], true
+		case "mix-sigmoid":
+			rules.MixSigmoid, ruleSet = sigmoids[2], true
+		case "time-step":
+			opts.TimeStep.Mode = mode
+		case "integrator":
+			opts.TimeStep.Integrator = integrator
+		case "dt":
+			opts.TimeStep.Dt = f.dt
+		}
+	})
+	if ruleSet {
+		opts.Rule = rules
+	}
+	if opts.Boundary, err = smoothlife.ParseBoundary(f.boundary); err != nil {
+		return opts, err
+	}
+	opts.BoundaryValue = f.boundaryValue
+	opts.Seed = f.seed
+	if f.coupling < 0 || f.coupling > 1 {
+		return opts, fmt.Errorf("coupling must be in [0,1], got %v", f.coupling)
+	}
+	if f.channels > 1 {
+		opts = coupledOptions(opts, f.channels, f.coupling)
+	}
+	return opts, opts.Validate()
+}
+
+// defaultCoupling is the share of the densities a channel takes from the others
+const defaultCoupling = 0.2
+
+// coupledOptions gives every channel its own copy of the kernel in opts, with each
+// channel's rule seeing a share coupling of the other channels' densities
+func coupledOptions(opts smoothlife.Options, channels int, coupling float64) smoothlife.Options {
+	opts.Channels = channels
+	opts.Kernels = make([]smoothlife.Kernel, channels)
+	opts.Weights = make([][]float64, channels)
+	for c := 0; c < channels; c++ {
+		opts.Kernels[c] = smoothlife.Kernel{
+			Source:      c,
+			InnerRadius: opts.InnerRadius,
+			OuterRadius: opts.OuterRadius,
+			LogRes:      opts.LogRes,
+			Lenia:       opts.Lenia,
+		}
+		opts.Weights[c] = make([]float64, channels)
+		for k := 0; k < channels; k++ {
+			if k == c {
+				opts.Weights[c][k] = 1 - coupling
+			} else {
+				opts.Weights[c][k] = coupling / float64(channels-1)
+			}
+		}
+	}
+	return opts
+}
+
+// generator builds the initial state generator chosen by -image or -init, sized
+// for sim
+func (f *simulationFlags) generator(sim *smoothlife.Simulation) (smoothlife.Generator, error) {
+	if f.image == "" {
+		return smoothlife.ParseGenerator(f.init, sim.Multipliers().OuterRadius())
+	}
+	file, err := os.Open(f.image)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := smoothlife.DecodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+	seed := smoothlife.ImageSeed{Image: img, Invert: f.imageInvert, Threshold: f.imageThreshold}
+	if seed.Fit, err = smoothlife.ParseFit(f.imageFit); err != nil {
+		return nil, err
+	}
+	if seed.Filter, err = smoothlife.ParseResample(f.imageFilter); err != nil {
+		return nil, err
+	}
+	return seed, seed.Validate()
+}
+
+// initSpec describes the initial state for the metadata of a run
+func (f *simulationFlags) initSpec() string {
+	if f.image == "" {
+		return f.init
+	}
+	return fmt.Sprintf("image:%s,fit=%s,filter=%s,invert=%t,threshold=%v", f.image, f.imageFit, f.imageFilter, f.imageInvert, f.imageThreshold)
+}
@@ -0,0 +1,101 @@
+//go:build viewer
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	minBrushRadius = 1
+	maxBrushRadius = 256
+	// brushScale is the factor one wheel notch or key press scales the radius by
+	brushScale = 1.15
+	// intensityStep is what one wheel notch or key press adds to the intensity
+	intensityStep = 0.05
+)
+
+var brushColor = color.NRGBA{R: 0xff, G: 0x8c, B: 0x00, A: 0xff}
+
+// painter paints on every channel of the simulation with the mouse. Left-drag adds
+// life and right-drag erases it. The wheel or [ and ] change the radius, shift with
+// the wheel or - and = change the intensity.
+type painter struct {
+	brush    smoothlife.Brush
+	dragging bool
+	lastX    float64
+	lastY    float64
+}
+
+func newPainter(radius float64) *painter {
+	return &painter{brush: smoothlife.Brush{Radius: radius, Intensity: 0.5}}
+}
+
+// update handles the input of a frame, queueing strokes on the loop that runs sim
+func (p *painter) update(l *loop, sim *smoothlife.Simulation, width int, height int) {
+	_, wheel := ebiten.Wheel()
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		p.adjustIntensity(wheel * intensityStep)
+	} else if wheel != 0 {
+		p.scaleRadius(math.Pow(brushScale, wheel))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		p.scaleRadius(brushScale)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		p.scaleRadius(1 / brushScale)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+		p.adjustIntensity(intensityStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+		p.adjustIntensity(-intensityStep)
+	}
+
+	add := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	erase := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+	if !add && !erase {
+		p.dragging = false
+		return
+	}
+	cx, cy := ebiten.CursorPosition()
+	x, y := float64(cx), float64(cy)
+	if !p.dragging {
+		// Only start strokes inside the window
+		if cx < 0 || cy < 0 || cx >= width || cy >= height {
+			return
+		}
+		p.lastX, p.lastY = x, y
+	}
+	brush, x0, y0 := p.brush, p.lastX, p.lastY
+	l.do(func() {
+		for c := 0; c < sim.Channels(); c++ {
+			brush.Stroke(sim.Channel(c), x0, y0, x, y, erase && !add)
+		}
+	})
+	p.dragging = true
+	p.lastX, p.lastY = x, y
+}
+
+func (p *painter) scaleRadius(factor float64) {
+	p.brush.Radius = smoothlife.Clamp(p.brush.Radius*factor, minBrushRadius, maxBrushRadius)
+}
+
+func (p *painter) adjustIntensity(delta float64) {
+	p.brush.Intensity = smoothlife.Clamp(p.brush.Intensity+delta, intensityStep, 1)
+}
+
+// draw outlines the brush around the cursor, more opaque at higher intensities
+func (p *painter) draw(screen *ebiten.Image) {
+	cx, cy := ebiten.CursorPosition()
+	clr := brushColor
+	clr.A = uint8(math.Round(0x40 + 0xbf*p.brush.Intensity))
+	vector.StrokeCircle(screen, float32(cx), float32(cy), float32(p.brush.Radius), 1, clr, true)
+}
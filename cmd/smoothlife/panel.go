@@ -0,0 +1,158 @@
+//go:build viewer
+
+package main
+
+import (
+	"image/color"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	panelWidth = 200
+	// panelRow is the height of a slider, its label above its track
+	panelRow    = 32
+	panelMargin = 8
+	knobRadius  = 5
+)
+
+var (
+	trackColor    = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	disabledColor = color.NRGBA{R: 0x40, G: 0x40, B: 0x40, A: 0xff}
+)
+
+// panel shows a slider for each of the parameters in the top right corner of the
+// window. Rule and dt changes reach the simulation while a slider is dragged, and
+// radius changes, which rebuild the kernels, once it is let go. u undoes the edits
+// one at a time.
+type panel struct {
+	visible bool
+	// captured is set while a mouse press that began on the panel is held, so that
+	// it does not paint
+	captured bool
+	// dragged is the parameter being dragged or -1, before its value when the drag
+	// began and value its value now
+	dragged int
+	before  float64
+	value   float64
+	history edits
+}
+
+func newPanel() *panel {
+	return &panel{dragged: -1}
+}
+
+// left is the x coordinate of the panel in a window width wide
+func (p *panel) left(width int) int {
+	return max(0, width-panelWidth)
+}
+
+func (p *panel) height() int {
+	return len(parameters)*panelRow + 2*panelMargin + hudLine
+}
+
+// update handles the mouse over the panel and reports whether it took the input
+// of this tick away from the painter
+func (p *panel) update(g *Game) bool {
+	pressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+	if !pressed {
+		p.captured = false
+		if p.dragged >= 0 {
+			p.release(g)
+		}
+		return false
+	}
+	if !p.visible {
+		return p.captured
+	}
+	x, y := ebiten.CursorPosition()
+	left := p.left(g.width)
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		if x < left || y >= p.height() {
+			return false
+		}
+		p.captured = true
+		i := (y - panelMargin) / panelRow
+		if i >= 0 && i < len(parameters) && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			if v, ok := parameters[i].get(g.frame.options); ok {
+				p.dragged, p.before, p.value = i, v, v
+			}
+		}
+	}
+	if p.dragged >= 0 {
+		param := parameters[p.dragged]
+		v := param.min + (param.max-param.min)*float64(x-left-panelMargin)/float64(panelWidth-2*panelMargin)
+		v = param.clamp(g.frame.options, v)
+		// The knob stays at the last value the tuner accepts
+		if v != p.value && param.check(g.frame.options, v) == nil {
+			p.value = v
+			if !param.kernel {
+				p.apply(g, p.dragged, v)
+			}
+		}
+	}
+	return p.captured
+}
+
+// release ends a drag, applying a radius and recording the edit if it changed the
+// value. The drag only moves to values the tuner accepts.
+func (p *panel) release(g *Game) {
+	if p.value != p.before {
+		if parameters[p.dragged].kernel {
+			p.apply(g, p.dragged, p.value)
+		}
+		p.history.record(edit{parameter: p.dragged, before: p.before, after: p.value})
+	}
+	p.dragged = -1
+}
+
+func (p *panel) apply(g *Game, i int, v float64) {
+	g.tuner.change(func(opts *smoothlife.Options) { parameters[i].set(opts, v) })
+}
+
+// undo puts back the value from before the latest edit
+func (p *panel) undo(g *Game) {
+	e, ok := p.history.undo()
+	if !ok {
+		g.showMessage("nothing to undo")
+		return
+	}
+	p.apply(g, e.parameter, e.before)
+	g.showMessage("undo: " + parameters[e.parameter].describe(e.before))
+}
+
+func (p *panel) draw(screen *ebiten.Image, f *frame, width int) {
+	if !p.visible {
+		return
+	}
+	left := p.left(width)
+	vector.DrawFilledRect(screen, float32(left), 0, panelWidth, float32(p.height()), color.NRGBA{A: 0xa0}, false)
+	trackLeft, trackWidth := float32(left+panelMargin), float32(panelWidth-2*panelMargin)
+	for i, param := range parameters {
+		y := panelMargin + i*panelRow
+		v, ok := param.get(f.options)
+		if i == p.dragged {
+			v = p.value
+		}
+		trackY := float32(y + panelRow - knobRadius - 4)
+		if !ok {
+			ebitenutil.DebugPrintAt(screen, param.name+" -", left+panelMargin, y)
+			vector.StrokeLine(screen, trackLeft, trackY, trackLeft+trackWidth, trackY, 2, disabledColor, false)
+			continue
+		}
+		ebitenutil.DebugPrintAt(screen, param.describe(v), left+panelMargin, y)
+		vector.StrokeLine(screen, trackLeft, trackY, trackLeft+trackWidth, trackY, 2, trackColor, false)
+		knob := color.Color(color.White)
+		if i == p.dragged {
+			knob = brushColor
+		}
+		fraction := float32(smoothlife.Clamp((v-param.min)/(param.max-param.min), 0, 1))
+		vector.DrawFilledCircle(screen, trackLeft+fraction*trackWidth, trackY, knobRadius, knob, true)
+	}
+	ebitenutil.DebugPrintAt(screen, "u undo", left+panelMargin, p.height()-panelMargin-hudLine)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// parameter is a number of the options that the slider panel edits. get reports
+// false for options without it, such as the radii of a Lenia kernel.
+type parameter struct {
+	name   string
+	min    float64
+	max    float64
+	format string
+	// kernel parameters rebuild the kernels, so the panel applies them once a drag ends
+	kernel bool
+	get    func(opts smoothlife.Options) (float64, bool)
+	set    func(opts *smoothlife.Options, v float64)
+	// limit narrows the range by the other options, nil leaves it alone
+	limit func(opts smoothlife.Options, v float64) float64
+}
+
+// minAnnulus is how far the panel keeps the outer radius above the inner
+const minAnnulus = 1
+
+var parameters = []parameter{
+	ruleParameter("B1", 0, 1, func(r *smoothlife.BasicRules) *float64 { return &r.B1 }),
+	ruleParameter("B2", 0, 1, func(r *smoothlife.BasicRules) *float64 { return &r.B2 }),
+	ruleParameter("D1", 0, 1, func(r *smoothlife.BasicRules) *float64 { return &r.D1 }),
+	ruleParameter("D2", 0, 1, func(r *smoothlife.BasicRules) *float64 { return &r.D2 }),
+	ruleParameter("N", 0.001, 0.2, func(r *smoothlife.BasicRules) *float64 { return &r.N }),
+	ruleParameter("M", 0.001, 0.5, func(r *smoothlife.BasicRules) *float64 { return &r.M }),
+	{
+		name: "inner", min: 1, max: 40, format: "%.1f", kernel: true,
+		get: func(opts smoothlife.Options) (float64, bool) { return opts.InnerRadius, opts.Lenia == nil },
+		set: func(opts *smoothlife.Options, v float64) { setRadii(opts, v, opts.OuterRadius) },
+		limit: func(opts smoothlife.Options, v float64) float64 {
+			return math.Min(v, opts.OuterRadius-minAnnulus)
+		},
+	},
+	{
+		name: "outer", min: 2, max: 120, format: "%.1f", kernel: true,
+		get: func(opts smoothlife.Options) (float64, bool) { return opts.OuterRadius, opts.Lenia == nil },
+		set: func(opts *smoothlife.Options, v float64) { setRadii(opts, opts.InnerRadius, v) },
+		limit: func(opts smoothlife.Options, v float64) float64 {
+			return math.Max(v, opts.InnerRadius+minAnnulus)
+		},
+	},
+	{
+		name: "dt", min: 0.005, max: 1, format: "%.3f",
+		get: func(opts smoothlife.Options) (float64, bool) { return opts.TimeStep.Dt, true },
+		set: func(opts *smoothlife.Options, v float64) { opts.TimeStep.Dt = v },
+	},
+}
+
+// ruleParameter edits a field of BasicRules
+func ruleParameter(name string, min float64, max float64, field func(r *smoothlife.BasicRules) *float64) parameter {
+	return parameter{
+		name: name, min: min, max: max, format: "%.3f",
+		get: func(opts smoothlife.Options) (float64, bool) {
+			rules, ok := opts.Rule.(smoothlife.BasicRules)
+			return *field(&rules), ok
+		},
+		set: func(opts *smoothlife.Options, v float64) {
+			if rules, ok := opts.Rule.(smoothlife.BasicRules); ok {
+				*field(&rules) = v
+				opts.Rule = rules
+			}
+		},
+	}
+}
+
+// setRadii changes the radii of opts and of every kernel built from them
+func setRadii(opts *smoothlife.Options, inner float64, outer float64) {
+	opts.InnerRadius, opts.OuterRadius = inner, outer
+	if opts.Kernels != nil {
+		kernels := make([]smoothlife.Kernel, len(opts.Kernels))
+		for i, k := range opts.Kernels {
+			if k.Lenia == nil {
+				k.InnerRadius, k.OuterRadius = inner, outer
+			}
+			kernels[i] = k
+		}
+		opts.Kernels = kernels
+	}
+}
+
+// clamp limits v to the range of the parameter in opts
+func (p parameter) clamp(opts smoothlife.Options, v float64) float64 {
+	v = smoothlife.Clamp(v, p.min, p.max)
+	if p.limit != nil {
+		v = p.limit(opts, v)
+	}
+	return v
+}
+
+// check reports why a tuner would refuse opts with the parameter set to v
+func (p parameter) check(opts smoothlife.Options, v float64) error {
+	changed := opts
+	p.set(&changed, v)
+	return checkChange(opts, changed)
+}
+
+func (p parameter) describe(v float64) string {
+	return fmt.Sprintf("%s "+p.format, p.name, v)
+}
+
+// maxEdits is how many edits the panel can undo
+const maxEdits = 100
+
+// edit is a change of a parameter made with the panel
+type edit struct {
+	parameter int
+	before    float64
+	after     float64
+}
+
+// edits remembers the changes made with the panel, newest last, so that they can
+// be undone
+type edits []edit
+
+func (e *edits) record(change edit) {
+	if change.before == change.after {
+		return
+	}
+	*e = append(*e, change)
+	if len(*e) > maxEdits {
+		*e = (*e)[len(*e)-maxEdits:]
+	}
+}
+
+// undo removes the latest edit and returns it
+func (e *edits) undo() (edit, bool) {
+	if len(*e) == 0 {
+		return edit{}, false
+	}
+	last := (*e)[len(*e)-1]
+	*e = (*e)[:len(*e)-1]
+	return last, true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+func TestParameters(t *testing.T) {
+	coupled := coupledOptions(smoothlife.DefaultOptions(), 2, defaultCoupling)
+	cases := []struct {
+		name      string
+		opts      smoothlife.Options
+		available map[string]bool
+	}{
+		{"SmoothLife", smoothlife.DefaultOptions(), map[string]bool{"B1": true, "M": true, "inner": true, "outer": true, "dt": true}},
+		{"Coupled channels", coupled, map[string]bool{"B1": true, "M": true, "inner": true, "outer": true, "dt": true}},
+		{"Lenia", smoothlife.LeniaOptions(), map[string]bool{"B1": false, "M": false, "inner": false, "outer": false, "dt": true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, param := range parameters {
+				available, ok := tc.available[param.name]
+				if !ok {
+					available = tc.available["B1"]
+				}
+				if _, got := param.get(tc.opts); got != available {
+					t.Errorf("%s available %v; want %v", param.name, got, available)
+					continue
+				}
+				if !available {
+					continue
+				}
+				opts := tc.opts
+				want := (param.min + param.max) / 2
+				param.set(&opts, want)
+				if v, _ := param.get(opts); v != want {
+					t.Errorf("%s is %v after setting %v", param.name, v, want)
+				}
+			}
+		})
+	}
+
+	// The radii reach every kernel, leaving those the coupled options started with
+	opts := coupled
+	setRadii(&opts, 5, 15)
+	for i, k := range opts.Kernels {
+		if k.InnerRadius != 5 || k.OuterRadius != 15 || k.Source != i {
+			t.Errorf("kernel %d is %+v", i, k)
+		}
+	}
+	if coupled.Kernels[0].OuterRadius == 15 {
+		t.Error("setRadii changed the kernels of the options it was given")
+	}
+}
+
+func TestParameterLimits(t *testing.T) {
+	opts := smoothlife.DefaultOptions()
+	setRadii(&opts, 5, 15)
+	byName := map[string]parameter{}
+	for _, param := range parameters {
+		byName[param.name] = param
+	}
+	cases := []struct {
+		name  string
+		param string
+		v     float64
+		want  float64
+	}{
+		{"Inner below outer", "inner", 12, 12},
+		{"Inner past outer", "inner", 30, 15 - minAnnulus},
+		{"Outer above inner", "outer", 8, 8},
+		{"Outer past inner", "outer", 3, 5 + minAnnulus},
+		{"Outer past its range", "outer", 500, 120},
+		{"Rule past its range", "B1", -1, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			param := byName[tc.param]
+			got := param.clamp(opts, tc.v)
+			if got != tc.want {
+				t.Errorf("clamp(%v) = %v; want %v", tc.v, got, tc.want)
+			}
+			if err := param.check(opts, got); err != nil {
+				t.Errorf("the tuner refuses the clamped value: %v", err)
+			}
+		})
+	}
+
+	if err := byName["inner"].check(opts, 15); err == nil {
+		t.Error("check accepted an inner radius equal to the outer")
+	}
+}
+
+func TestEdits(t *testing.T) {
+	var history edits
+	history.record(edit{parameter: 0, before: 0.2, after: 0.2})
+	if len(history) != 0 {
+		t.Error("recorded an edit that changed nothing")
+	}
+	for i := 0; i < maxEdits+5; i++ {
+		history.record(edit{parameter: 1, before: float64(i), after: float64(i + 1)})
+	}
+	if len(history) != maxEdits {
+		t.Errorf("kept %d edits; want %d", len(history), maxEdits)
+	}
+	e, ok := history.undo()
+	if !ok || e.before != maxEdits+4 {
+		t.Errorf("undo gave %+v, %v; want the latest edit", e, ok)
+	}
+	for ok {
+		_, ok = history.undo()
+	}
+	if _, ok := history.undo(); ok {
+		t.Error("undo of an empty history succeeded")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// run simulates without a display, writing PNG frames or an animated GIF
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	simFlags := addSimulationFlags(fs)
+	renderFlags := addRenderFlags(fs)
+	steps := fs.Int("steps", 200, "number of steps to simulate")
+	every := fs.Int("every", 10, "write a frame every this many steps")
+	out := fs.String("out", "frames", "output directory")
+	asGIF := fs.Bool("gif", false, "write a single animated GIF instead of PNG frames")
+	delay := fs.Int("delay", 4, "delay between GIF frames in hundredths of a second")
+	npz := fs.Bool("npz", false, "also write the field, kernels and densities of every frame as NumPy archives")
+	if err := simFlags.parse(args); err != nil {
+		return err
+	}
+
+	if *steps < 0 {
+		return fmt.Errorf("steps must not be negative, got %d", *steps)
+	}
+	if *every < 1 {
+		return errors.New("every must be at least 1")
+	}
+	opts, err := simFlags.options()
+	if err != nil {
+		return err
+	}
+	sim, err := smoothlife.ConstructSimulation(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	renderer, err := renderFlags.renderer()
+	if err != nil {
+		return err
+	}
+	generator, err := simFlags.generator(sim)
+	if err != nil {
+		return err
+	}
+	if err := writeMetadata(filepath.Join(*out, "metadata.json"), sim, simFlags.config, simFlags.initSpec(), *steps, *every); err != nil {
+		return err
+	}
+	if err := sim.Generate(generator); err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	animation := &gif.GIF{}
+	frames := 0
+	for step := 0; step <= *steps; step++ {
+		if step > 0 {
+			sim.Step()
+		}
+		if step%*every != 0 {
+			continue
+		}
+		renderer.Render(img, sim.SmoothLife)
+		if *asGIF {
+			animation.Image = append(animation.Image, paletted(img, renderer.Palette(sim.SmoothLife)))
+			animation.Delay = append(animation.Delay, *delay)
+		} else if err := writePNG(filepath.Join(*out, fmt.Sprintf("frame_%06d.png", step)), img); err != nil {
+			return err
+		}
+		if *npz {
+			if err := writeNPZ(filepath.Join(*out, fmt.Sprintf("state_%06d.npz", step)), sim); err != nil {
+				return err
+			}
+		}
+		frames++
+	}
+
+	if *asGIF {
+		if err := writeGIF(filepath.Join(*out, "smoothlife.gif"), animation); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("wrote %d frames of %d steps to %s, seed %d\n", frames, *steps, *out, sim.Seed())
+	return nil
+}
+
+// metadata describes a run well enough to reproduce it
+type metadata struct {
+	Config      string  `json:"config,omitempty"`
+	Seed        int64   `json:"seed"`
+	Init        string  `json:"init"`
+	Steps       int     `json:"steps"`
+	Every       int     `json:"every"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	Channels    int     `json:"channels"`
+	InnerRadius float64 `json:"inner_radius"`
+	OuterRadius float64 `json:"outer_radius"`
+	LogRes      float64 `json:"logres"`
+	Rule        string  `json:"rule"`
+	TimeStep    string  `json:"time_step"`
+	Boundary    string  `json:"boundary"`
+}
+
+func writeMetadata(path string, sim *smoothlife.Simulation, config string, init string, steps int, every int) error {
+	opts := sim.Options()
+	data, err := json.MarshalIndent(metadata{
+		Config:      config,
+		Seed:        sim.Seed(),
+		Init:        init,
+		Steps:       steps,
+		Every:       every,
+		Width:       opts.Width,
+		Height:      opts.Height,
+		Channels:    sim.Channels(),
+		InnerRadius: opts.InnerRadius,
+		OuterRadius: opts.OuterRadius,
+		LogRes:      opts.LogRes,
+		Rule:        fmt.Sprintf("%T%+v", opts.Rule, opts.Rule),
+		TimeStep:    fmt.Sprintf("%+v", opts.TimeStep),
+		Boundary:    opts.Boundary.String(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeGIF(path string, animation *gif.GIF) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, animation); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeNPZ(path string, sim *smoothlife.Simulation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := sim.SaveNPZ(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunWritesFrames(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		files []string
+	}{
+		{"PNG frames", []string{"-every", "2"}, []string{"metadata.json", "frame_000000.png", "frame_000002.png", "frame_000004.png"}},
+		{"Animated GIF", []string{"-every", "2", "-gif"}, []string{"metadata.json", "smoothlife.gif"}},
+		{"Value noise", []string{"-every", "4", "-init", "value-noise:octaves=2"}, []string{"metadata.json", "frame_000000.png", "frame_000004.png"}},
+		{"Colour map GIF", []string{"-every", "2", "-gif", "-colormap", "magma", "-render", "rate"}, []string{"metadata.json", "smoothlife.gif"}},
+		{"NumPy archives", []string{"-every", "4", "-npz"}, []string{"metadata.json", "frame_000000.png", "frame_000004.png", "state_000000.npz", "state_000004.npz"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := t.TempDir()
+			args := append([]string{"-width", "48", "-height", "32", "-inner", "3", "-outer", "9", "-seed", "1", "-steps", "4", "-out", out}, tc.args...)
+			if err := run(args); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tc.files) {
+				t.Errorf("run wrote %d files; want %v", len(entries), tc.files)
+			}
+			for _, name := range tc.files {
+				if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+					t.Errorf("missing output: %v", err)
+				}
+			}
+		})
+	}
+
+	out := t.TempDir()
+	if err := run([]string{"-width", "48", "-height", "32", "-inner", "3", "-outer", "9", "-steps", "6", "-every", "3", "-gif", "-out", out}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(out, "smoothlife.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	animation, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animation.Image) != 3 {
+		t.Errorf("GIF has %d frames; want 3", len(animation.Image))
+	}
+}
+
+func TestRunIsReproducible(t *testing.T) {
+	outs := []string{t.TempDir(), t.TempDir()}
+	for _, out := range outs {
+		if err := run([]string{"-width", "48", "-height", "32", "-inner", "3", "-outer", "9", "-seed", "7", "-steps", "3", "-every", "3", "-out", out}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"metadata.json", "frame_000003.png"} {
+		a, err := os.ReadFile(filepath.Join(outs[0], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(outs[1], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s differs between runs with the same seed", name)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(outs[0], "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Seed != 7 {
+		t.Errorf("metadata records seed %d; want 7", meta.Seed)
+	}
+}
+
+func TestRunFromImage(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	path := filepath.Join(dir, "seed.png")
+	if err := writePNG(path, img); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	args := []string{"-width", "48", "-height", "32", "-inner", "3", "-outer", "9", "-steps", "0", "-out", out,
+		"-image", path, "-image-filter", "bicubic", "-image-invert"}
+	if err := run(args); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(out, "frame_000000.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	frame, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An inverted light grey image seeds a dark grey field
+	if r, _, _, _ := frame.At(24, 16).RGBA(); r == 0 || r > 0x8000 {
+		t.Errorf("seeded pixel has red %#x; want a dark grey", r)
+	}
+
+	if err := run(append(args, "-image-fit", "squash")); err == nil {
+		t.Error("run accepted an unknown fit")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// tuner changes the options of the simulation the loop runs without touching its
+// field. Rule and time step changes take effect from the next step. Kernel and
+// boundary changes are built on another goroutine while the simulation keeps
+// stepping and swapped in between two steps, a later change winning over one still
+// being built.
+type tuner struct {
+	loop *loop
+	sim  *smoothlife.Simulation
+	// generation counts the changes and target is the latest, which differs from
+	// the options of the simulation while its kernels are being rebuilt. The loop's
+	// goroutine owns them.
+	generation uint64
+	target     smoothlife.Options
+	rebuilding bool
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func newTuner(l *loop, sim *smoothlife.Simulation) *tuner {
+	return &tuner{loop: l, sim: sim}
+}
+
+// set queues a change to opts. The size of the grid, the number of channels and
+// the seed cannot change.
+func (t *tuner) set(opts smoothlife.Options) {
+	t.loop.do(func() { t.apply(opts) })
+}
+
+// change queues a change made by edit to the latest options
+func (t *tuner) change(edit func(opts *smoothlife.Options)) {
+	t.loop.do(func() {
+		opts := t.options()
+		edit(&opts)
+		t.apply(opts)
+	})
+}
+
+// options returns the latest options on the loop's goroutine, including those of
+// kernels still being built
+func (t *tuner) options() smoothlife.Options {
+	if t.rebuilding {
+		return t.target
+	}
+	return t.sim.Options()
+}
+
+// apply changes the simulation to opts on the loop's goroutine
+func (t *tuner) apply(opts smoothlife.Options) {
+	current := t.sim.Options()
+	opts.Seed = current.Seed
+	if err := checkChange(current, opts); err != nil {
+		t.notify(err.Error())
+		return
+	}
+	if t.rebuilding && !needsRebuild(t.target, opts) {
+		// The kernels being built stay right, so only the rules move on
+		t.setRules(opts)
+		t.target = opts
+		return
+	}
+	t.generation++
+	t.rebuilding = false
+	if !needsRebuild(current, opts) {
+		t.setRules(opts)
+		return
+	}
+
+	generation := t.generation
+	t.target, t.rebuilding = opts, true
+	t.notify("rebuilding the kernels")
+	go func() {
+		start := time.Now()
+		next, err := smoothlife.ConstructSimulation(opts)
+		t.loop.do(func() {
+			if generation != t.generation {
+				// A later change replaced this one
+				return
+			}
+			t.rebuilding = false
+			if err == nil {
+				err = t.sim.Adopt(next)
+			}
+			if err != nil {
+				t.notify(err.Error())
+				return
+			}
+			// Rule changes made during the build
+			t.setRules(t.target)
+			t.notify(fmt.Sprintf("kernels rebuilt in %v", time.Since(start).Round(time.Millisecond)))
+		})
+	}()
+}
+
+// checkChange reports why a tuner would refuse to change the options current to opts
+func checkChange(current, opts smoothlife.Options) error {
+	if opts.Width != current.Width || opts.Height != current.Height || max(opts.Channels, 1) != max(current.Channels, 1) {
+		return errors.New("the grid size and channels only change on a restart")
+	}
+	return opts.Validate()
+}
+
+// setRules swaps the rule and time step of opts into the simulation
+func (t *tuner) setRules(opts smoothlife.Options) {
+	if !reflect.DeepEqual(t.sim.Options().Rule, opts.Rule) {
+		t.sim.SetRule(opts.Rule)
+	}
+	t.sim.SetTimeStep(opts.TimeStep)
+}
+
+// needsRebuild reports whether going from a to b changes more than the rule and
+// time step, which the simulation can swap without rebuilding its kernels
+func needsRebuild(a, b smoothlife.Options) bool {
+	for _, o := range []*smoothlife.Options{&a, &b} {
+		o.Rule, o.TimeStep, o.Seed = nil, smoothlife.TimeStep{}, 0
+	}
+	return !reflect.DeepEqual(a, b)
+}
+
+func (t *tuner) notify(message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, message)
+}
+
+// notices returns the messages about changes since the last call
+func (t *tuner) notices() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	messages := t.messages
+	t.messages = nil
+	return messages
+}
+
+// watchInterval is how often a watcher looks at its file
+const watchInterval = 500 * time.Millisecond
+
+// watcher hands the options of a config file to a tuner whenever the file changes.
+// load builds the options from the file and the command line, so flags given on
+// the command line still win over its keys.
+type watcher struct {
+	path     string
+	load     func() (smoothlife.Options, error)
+	tuner    *tuner
+	modified time.Time
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+func newWatcher(path string, load func() (smoothlife.Options, error), t *tuner) *watcher {
+	w := &watcher{path: path, load: load, tuner: t, quit: make(chan struct{}), done: make(chan struct{})}
+	if info, err := os.Stat(path); err == nil {
+		w.modified = info.ModTime()
+	}
+	return w
+}
+
+// start polls the file on its own goroutine until stop
+func (w *watcher) start() {
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+func (w *watcher) stop() {
+	close(w.quit)
+	<-w.done
+}
+
+// check reloads the file if it changed since the last look. A file that fails to
+// load is reported and tried again on its next change.
+func (w *watcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil || info.ModTime().Equal(w.modified) {
+		// Editors that replace the file may leave it missing for a moment
+		return
+	}
+	w.modified = info.ModTime()
+	opts, err := w.load()
+	if err != nil {
+		w.tuner.notify(err.Error())
+		return
+	}
+	w.tuner.notify("reloaded " + w.path)
+	w.tuner.set(opts)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"SmoothLifeGo/smoothlife"
+)
+
+// tunedLoop starts a loop whose frames carry the options of sim
+func tunedLoop(t *testing.T, rate float64) (*loop, *tuner, *smoothlife.Simulation) {
+	t.Helper()
+	sim := loopSimulation(t)
+	l := newLoop(sim, rate, func(f *frame) { f.options = sim.Options() }, nil)
+	l.start()
+	t.Cleanup(l.stop)
+	return l, newTuner(l, sim), sim
+}
+
+func TestTunerSwapsRules(t *testing.T) {
+	l, tuner, sim := tunedLoop(t, 0)
+	kernels := sim.Multipliers()
+	opts := sim.Options()
+	rules := opts.Rule.(smoothlife.BasicRules)
+	rules.B1 = 0.25
+	opts.Rule = rules
+	opts.TimeStep = smoothlife.TimeStep{Mode: smoothlife.SmoothRelax, Dt: 0.05}
+	tuner.set(opts)
+
+	f := waitForFrame(t, l, func(f *frame) bool { return f.options.Rule == rules })
+	if f.options.TimeStep != opts.TimeStep {
+		t.Errorf("time step %+v; want %+v", f.options.TimeStep, opts.TimeStep)
+	}
+	done := make(chan bool)
+	l.do(func() { done <- sim.Multipliers() == kernels })
+	if !<-done {
+		t.Error("a rule change rebuilt the kernels")
+	}
+}
+
+func TestTunerRebuildsKernels(t *testing.T) {
+	l, tuner, sim := tunedLoop(t, 0)
+	opts := sim.Options()
+	steps := waitForFrame(t, l, func(f *frame) bool { return f.step > 0 }).step
+
+	// The later change wins over the one still being built
+	opts.OuterRadius = 10
+	tuner.set(opts)
+	opts.OuterRadius = 12
+	tuner.set(opts)
+	f := waitForFrame(t, l, func(f *frame) bool { return f.options.OuterRadius == 12 })
+	if f.step < steps || f.options.Seed != 1 {
+		t.Errorf("the rebuild went back to step %d with seed %d", f.step, f.options.Seed)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if r := l.frame().options.OuterRadius; r != 12 {
+		t.Errorf("the earlier change replaced the later one, outer radius %v", r)
+	}
+
+	var notices []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		notices = append(notices, tuner.notices()...)
+		if strings.HasPrefix(notices[len(notices)-1], "kernels rebuilt") {
+			break
+		}
+	}
+	if len(notices) == 0 || !strings.HasPrefix(notices[len(notices)-1], "kernels rebuilt") {
+		t.Errorf("notices %q do not report the rebuild", notices)
+	}
+}
+
+func TestTunerRejects(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(opts *smoothlife.Options)
+		want   string
+	}{
+		{"Grid size", func(opts *smoothlife.Options) { opts.Width = 64 }, "restart"},
+		{"Invalid time step", func(opts *smoothlife.Options) {
+			opts.TimeStep = smoothlife.TimeStep{Mode: smoothlife.SmoothRelax, Dt: -1}
+		}, "dt"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, tuner, sim := tunedLoop(t, 0)
+			opts := sim.Options()
+			tc.modify(&opts)
+			tuner.set(opts)
+			current := make(chan smoothlife.Options)
+			l.do(func() { current <- sim.Options() })
+			if got := <-current; got.Width != 48 || got.TimeStep.Dt != 0.1 {
+				t.Errorf("the simulation changed to %+v", got)
+			}
+			notices := tuner.notices()
+			if len(notices) != 1 || !strings.Contains(notices[0], tc.want) {
+				t.Errorf("notices %q; want one mentioning %q", notices, tc.want)
+			}
+		})
+	}
+}
+
+func TestWatcherReloadsConfig(t *testing.T) {
+	l, tuner, _ := tunedLoop(t, 0)
+	path := filepath.Join(t.TempDir(), "tune.toml")
+	write := func(config string, age time.Duration) {
+		if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Coarse file system clocks could otherwise hide the change
+		modified := time.Now().Add(-age)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() (smoothlife.Options, error) {
+		fs := flag.NewFlagSet("view", flag.ContinueOnError)
+		simFlags := addSimulationFlags(fs)
+		if err := simFlags.parse([]string{"-config", path, "-width", "48", "-height", "32", "-inner", "3"}); err != nil {
+			return smoothlife.Options{}, err
+		}
+		return simFlags.options()
+	}
+
+	write("[kernel]\nouter_radius = 9\n", time.Minute)
+	w := newWatcher(path, load, tuner)
+	w.check()
+	if notices := tuner.notices(); len(notices) != 0 {
+		t.Errorf("an unchanged file gave %q", notices)
+	}
+
+	write("[kernel]\nouter_radius = 9\n\n[rule]\nb1 = 0.26\n", 0)
+	w.check()
+	waitForFrame(t, l, func(f *frame) bool { return f.options.Rule.(smoothlife.BasicRules).B1 == 0.26 })
+
+	write("[rule]\nb1 = 2\n", time.Second)
+	w.check()
+	if notices := tuner.notices(); len(notices) == 0 || !strings.Contains(notices[len(notices)-1], "tune.toml:2: rule.b1") {
+		t.Errorf("notices %q do not report the bad key", notices)
+	}
+}
+
+func TestTunerChangesDuringRebuild(t *testing.T) {
+	l, tuner, _ := tunedLoop(t, 0)
+	tuner.change(func(opts *smoothlife.Options) { setRadii(opts, 4, 12) })
+	tuner.change(func(opts *smoothlife.Options) {
+		rules := opts.Rule.(smoothlife.BasicRules)
+		rules.B1 = 0.25
+		opts.Rule = rules
+	})
+	// The frames carry the options of the simulation, which take the radii once
+	// the kernels are built
+	f := waitForFrame(t, l, func(f *frame) bool { return f.options.OuterRadius == 12 })
+	if f.options.Rule.(smoothlife.BasicRules).B1 != 0.25 {
+		t.Errorf("the rule change during the rebuild was lost: %+v", f.options.Rule)
+	}
+}
@@ -0,0 +1,224 @@
+//go:build viewer
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+
+	"SmoothLifeGo/smoothlife"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var logger *log.Logger
+
+// Game is the window. The simulation runs on the loop's goroutine, which owns it
+// along with the renderer, the inspector's measurements and the HUD's history; the
+// window only reads the frames the loop publishes and queues changes through it.
+type Game struct {
+	sim       *smoothlife.Simulation
+	loop      *loop
+	tuner     *tuner
+	generator smoothlife.Generator
+	painter   *painter
+	panel     *panel
+	renderer  *smoothlife.Renderer
+	hud       *hud
+	inspector *inspector
+	frame     *frame
+	width     int
+	height    int
+
+	// rate is the target number of steps per second, fast runs as fast as possible
+	// instead. colormap and renderMode mirror the renderer, which the loop owns, and
+	// preset is the last one applied.
+	paused     bool
+	rate       float64
+	fast       bool
+	colormap   string
+	renderMode smoothlife.RenderMode
+	preset     string
+	tooltip    bool
+
+	showHelp     bool
+	message      string
+	messageTimer int
+}
+
+// NewGame draws the initial state and starts the simulation loop, a rate of 0 runs
+// it as fast as possible
+func NewGame(sim *smoothlife.Simulation, generator smoothlife.Generator, renderer *smoothlife.Renderer, rate float64) *Game {
+	opts := sim.Options()
+	g := &Game{
+		sim:        sim,
+		generator:  generator,
+		painter:    newPainter(sim.Multipliers().OuterRadius() / 2),
+		panel:      newPanel(),
+		renderer:   renderer,
+		hud:        newHUD(false),
+		inspector:  newInspector(opts.Width, opts.Height),
+		width:      opts.Width,
+		height:     opts.Height,
+		rate:       defaultRate,
+		fast:       rate == 0,
+		renderMode: renderer.Mode,
+		// Point newcomers at the bindings
+		message:      "press h for help",
+		messageTimer: messageTicks,
+	}
+	if rate > 0 {
+		g.rate = smoothlife.Clamp(rate, minRate, maxRate)
+	}
+	if renderer.Colormap != nil {
+		g.colormap = renderer.Colormap.Name
+	}
+	if err := sim.Generate(generator); err != nil {
+		logger.Printf("drawing the initial state: %v", err)
+		g.showMessage(err.Error())
+	}
+	g.loop = newLoop(sim, g.targetRate(), g.render, func() { g.hud.record(sim) })
+	g.tuner = newTuner(g.loop, sim)
+	g.loop.start()
+	g.frame = g.loop.frame()
+	return g
+}
+
+func (g *Game) Update() error {
+	g.frame = g.loop.frame()
+	for _, notice := range g.tuner.notices() {
+		logger.Print(notice)
+		g.showMessage(notice)
+	}
+	g.handleKeys()
+	if !g.panel.update(g) {
+		g.painter.update(g.loop, g.sim, g.width, g.height)
+	}
+	return nil
+}
+
+// render fills a frame on the loop's goroutine
+func (g *Game) render(f *frame) {
+	if g.inspector.active() {
+		g.inspector.render(f.img, g.sim)
+	} else {
+		g.renderer.Render(f.img, g.sim.SmoothLife)
+	}
+	g.inspector.capture(f, g.sim)
+	// The options asked for, whose kernels may still be being built
+	f.options = g.tuner.options()
+	f.stats = g.sim.Stats(0)
+	f.mass = g.hud.history(f.mass[:0])
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	screen.WritePixels(g.frame.img.Pix)
+	g.inspector.draw(screen, g.frame, g.tooltip)
+	g.hud.draw(screen, g.frame)
+	g.panel.draw(screen, g.frame, g.width)
+	g.painter.draw(screen)
+	g.drawOverlay(screen)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.width, g.height
+}
+
+// viewFlags are the flags of view
+type viewFlags struct {
+	sim     *simulationFlags
+	render  *renderFlags
+	showHUD *bool
+	rate    *float64
+	pprof   *string
+}
+
+func addViewFlags(fs *flag.FlagSet) viewFlags {
+	return viewFlags{
+		sim:     addSimulationFlags(fs),
+		render:  addRenderFlags(fs),
+		showHUD: fs.Bool("hud", false, "start with the statistics overlay shown, i toggles it"),
+		rate:    fs.Float64("rate", defaultRate, "target steps per second, 0 runs as fast as possible"),
+		pprof:   fs.String("pprof", "", "serve profiles at `address`, such as localhost:6060, none when empty"),
+	}
+}
+
+// reloadOptions parses args again, rereading the -config file they name
+func reloadOptions(args []string) (smoothlife.Options, error) {
+	fs := flag.NewFlagSet("view", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flags := addViewFlags(fs)
+	if err := flags.sim.parse(args); err != nil {
+		return smoothlife.Options{}, err
+	}
+	return flags.sim.options()
+}
+
+// view opens the simulation in an Ebiten window. Changes to the -config file apply
+// to the running simulation, see tuner.
+func view(args []string) error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	flags := addViewFlags(fs)
+	simFlags, renderFlags, showHUD, rate := flags.sim, flags.render, flags.showHUD, flags.rate
+	if err := simFlags.parse(args); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile("app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	defer logFile.Close()
+
+	logger = log.New(logFile, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
+	if *flags.pprof != "" {
+		listener, err := net.Listen("tcp", *flags.pprof)
+		if err != nil {
+			return fmt.Errorf("serving profiles: %w", err)
+		}
+		defer listener.Close()
+		logger.Printf("serving profiles at http://%s/debug/pprof/", listener.Addr())
+		go http.Serve(listener, nil)
+	}
+
+	opts, err := simFlags.options()
+	if err != nil {
+		return err
+	}
+	sim, err := smoothlife.ConstructSimulation(opts)
+	if err != nil {
+		return err
+	}
+	sim.Clear()
+	generator, err := simFlags.generator(sim)
+	if err != nil {
+		return err
+	}
+	renderer, err := renderFlags.renderer()
+	if err != nil {
+		return err
+	}
+	logger.Printf("seed %d", sim.Seed())
+	if *rate < 0 {
+		return fmt.Errorf("rate must not be negative, got %v", *rate)
+	}
+	game := NewGame(sim, generator, renderer, *rate)
+	defer game.loop.stop()
+	game.hud.visible = *showHUD
+	game.preset = simFlags.preset
+	if simFlags.config != "" {
+		w := newWatcher(simFlags.config, func() (smoothlife.Options, error) { return reloadOptions(args) }, game.tuner)
+		w.start()
+		defer w.stop()
+	}
+
+	ebiten.SetWindowSize(game.width, game.height)
+	ebiten.SetWindowTitle("SmoothLifeGo")
+	return ebiten.RunGame(game)
+}
@@ -0,0 +1,124 @@
+package smoothlife
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Boundary selects what the kernels see beyond the edges of the field
+type Boundary int
+
+const (
+	// BoundaryPeriodic wraps the field around like a torus
+	BoundaryPeriodic Boundary = iota
+	// BoundaryZero surrounds the field with dead cells
+	BoundaryZero
+	// BoundaryReflect mirrors the field at its edges
+	BoundaryReflect
+	// BoundaryFixed surrounds the field with cells held at a fixed value
+	BoundaryFixed
+)
+
+var boundaryNames = map[Boundary]string{
+	BoundaryPeriodic: "periodic",
+	BoundaryZero:     "zero",
+	BoundaryReflect:  "reflect",
+	BoundaryFixed:    "fixed",
+}
+
+func (b Boundary) String() string {
+	if name, ok := boundaryNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Boundary(%d)", int(b))
+}
+
+// ParseBoundary looks up a boundary by the name String returns
+func ParseBoundary(name string) (Boundary, error) {
+	for b, n := range boundaryNames {
+		if n == name {
+			return b, nil
+		}
+	}
+	return BoundaryPeriodic, fmt.Errorf("smoothlife: unknown boundary %q", name)
+}
+
+// kernelTail is the relative weight below which the edge of an antialiased disc
+// is treated as empty when sizing the padding
+const kernelTail = 1e-6
+
+// extent returns the distance beyond which the kernel has no significant weight
+func (k Kernel) extent() float64 {
+	if k.Lenia != nil {
+		return k.Lenia.R
+	}
+	return k.OuterRadius + math.Log(1/kernelTail)/k.LogRes
+}
+
+// setBoundary pads every convolution by pad cells on each side, filled according
+// to boundary. The kernels must already have been built for the padded size.
+func (sl *SmoothLife) setBoundary(boundary Boundary, value float64, pad int) {
+	if boundary == BoundaryZero {
+		value = 0
+	}
+	sl.boundary = boundary
+	sl.boundaryValue = value
+	sl.ws = newWorkspace(len(sl.rules), len(sl.kernels), sl.width, sl.height, pad)
+}
+
+// pad copies field into the middle of the padded buffer dst and fills the border
+// according to the boundary condition
+func (sl *SmoothLife) pad(dst []float64, field *mat.Dense) {
+	pad := sl.ws.pad
+	paddedWidth := sl.width + 2*pad
+	src := field.RawMatrix().Data
+	for i := 0; i < sl.height+2*pad; i++ {
+		row := dst[i*paddedWidth : (i+1)*paddedWidth]
+		y := i - pad
+		if (y < 0 || y >= sl.height) && sl.boundary != BoundaryReflect {
+			for j := range row {
+				row[j] = sl.boundaryValue
+			}
+			continue
+		}
+		y = reflectIndex(y, sl.height)
+		for j := range row {
+			x := j - pad
+			if x < 0 || x >= sl.width {
+				if sl.boundary != BoundaryReflect {
+					row[j] = sl.boundaryValue
+					continue
+				}
+				x = reflectIndex(x, sl.width)
+			}
+			row[j] = src[y*sl.width+x]
+		}
+	}
+}
+
+// reflectIndex mirrors an index into [0, size), repeating the edge cell like
+// numpy's symmetric padding
+func reflectIndex(i int, size int) int {
+	period := 2 * size
+	i %= period
+	if i < 0 {
+		i += period
+	}
+	if i >= size {
+		i = period - 1 - i
+	}
+	return i
+}
+
+// crop copies the middle of the padded buffer src into dst
+func (sl *SmoothLife) crop(dst *mat.Dense, src []float64) {
+	pad := sl.ws.pad
+	paddedWidth := sl.width + 2*pad
+	data := dst.RawMatrix().Data
+	for i := 0; i < sl.height; i++ {
+		start := (i+pad)*paddedWidth + pad
+		copy(data[i*sl.width:(i+1)*sl.width], src[start:start+sl.width])
+	}
+}
@@ -0,0 +1,51 @@
+package smoothlife
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Brush paints with an antialiased disc. Every dab moves the cells under the disc
+// towards the target value by Intensity times the disc profile, so holding the
+// brush still builds up gradually.
+type Brush struct {
+	Radius    float64
+	Intensity float64
+	// LogRes sets the sharpness of the edge as in AntialiasedCircle, 0 picks one from
+	// the grid size
+	LogRes float64
+}
+
+// Paint moves the cells around (x, y) towards 1
+func (b Brush) Paint(field *mat.Dense, x float64, y float64) {
+	b.dab(field, x, y, 1)
+}
+
+// Erase moves the cells around (x, y) towards 0
+func (b Brush) Erase(field *mat.Dense, x float64, y float64) {
+	b.dab(field, x, y, 0)
+}
+
+// Stroke dabs along the segment from (x0, y0) to (x1, y1), a quarter of the radius
+// apart, so that fast drags leave no gaps. The first end is not dabbed, it is where
+// the previous stroke ended.
+func (b Brush) Stroke(field *mat.Dense, x0 float64, y0 float64, x1 float64, y1 float64, erase bool) {
+	target := 1.0
+	if erase {
+		target = 0
+	}
+	dabs := max(1, int(math.Ceil(math.Hypot(x1-x0, y1-y0)/math.Max(b.Radius/4, 0.5))))
+	for i := 1; i <= dabs; i++ {
+		t := float64(i) / float64(dabs)
+		b.dab(field, Lerp(x0, x1, t), Lerp(y0, y1, t), target)
+	}
+}
+
+func (b Brush) dab(field *mat.Dense, x float64, y float64, target float64) {
+	logres := fieldLogRes(field, b.LogRes)
+	strength := Clamp(b.Intensity, 0, 1)
+	visit(field, x, y, b.Radius+math.Log(1/kernelTail)/logres, func(i, j int, r float64) {
+		field.Set(i, j, Lerp(field.At(i, j), target, strength*antialiasedDisc(r, b.Radius, logres)))
+	})
+}
@@ -0,0 +1,150 @@
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// colormapSize is the number of entries in the lookup table of a colour map
+const colormapSize = 256
+
+// Colormap maps values in [0,1] onto opaque colours, interpolating linearly between
+// evenly spaced stops
+type Colormap struct {
+	Name string
+	lut  [colormapSize]color.RGBA
+}
+
+// NewColormap builds a colour map through two or more evenly spaced stops
+func NewColormap(name string, stops ...color.Color) (*Colormap, error) {
+	if len(stops) < 2 {
+		return nil, errors.New("smoothlife: a colour map needs at least two stops")
+	}
+	rgba := make([]color.NRGBA, len(stops))
+	for i, stop := range stops {
+		rgba[i] = color.NRGBAModel.Convert(stop).(color.NRGBA)
+	}
+	cm := &Colormap{Name: name}
+	segments := float64(len(stops) - 1)
+	for i := range cm.lut {
+		t := float64(i) / (colormapSize - 1) * segments
+		k := min(int(t), len(stops)-2)
+		a, b := rgba[k], rgba[k+1]
+		f := t - float64(k)
+		cm.lut[i] = color.RGBA{
+			R: uint8(math.Round(Lerp(float64(a.R), float64(b.R), f))),
+			G: uint8(math.Round(Lerp(float64(a.G), float64(b.G), f))),
+			B: uint8(math.Round(Lerp(float64(a.B), float64(b.B), f))),
+			A: 0xff,
+		}
+	}
+	return cm, nil
+}
+
+// At returns the colour of v, clamped into [0,1]
+func (cm *Colormap) At(v float64) color.RGBA {
+	if !(v > 0) {
+		return cm.lut[0]
+	}
+	return cm.lut[min(colormapSize-1, int(v*(colormapSize-1)+0.5))]
+}
+
+// Palette returns every colour of the map, for paletted images
+func (cm *Colormap) Palette() color.Palette {
+	p := make(color.Palette, colormapSize)
+	for i, c := range cm.lut {
+		p[i] = c
+	}
+	return p
+}
+
+// mustColormap builds one of the colour maps below from hex stops
+func mustColormap(name string, hexes ...string) *Colormap {
+	stops := make([]color.Color, len(hexes))
+	for i, hex := range hexes {
+		c, err := ParseHexColor(hex)
+		if err != nil {
+			panic(err)
+		}
+		stops[i] = c
+	}
+	cm, err := NewColormap(name, stops...)
+	if err != nil {
+		panic(err)
+	}
+	return cm
+}
+
+var (
+	// Grayscale runs from black to white
+	Grayscale = mustColormap("grayscale", "#000000", "#ffffff")
+	// Viridis is matplotlib's perceptually uniform default, dark blue to yellow
+	Viridis = mustColormap("viridis",
+		"#440154", "#482878", "#3e4989", "#31688e", "#26828e",
+		"#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725")
+	// Magma is perceptually uniform from black through purple and orange to cream
+	Magma = mustColormap("magma",
+		"#000004", "#180f3d", "#440f76", "#721f81", "#9e2f7f",
+		"#cd4071", "#f1605d", "#fd9668", "#feca8d", "#fcfdbf")
+	// Twilight is cyclic, its ends meet in a pale grey with a dark middle, which suits
+	// signed values such as rates of change
+	Twilight = mustColormap("twilight",
+		"#e2d9e2", "#a1b6cd", "#6582bd", "#5a3f9e", "#2f1436",
+		"#7c2a4f", "#b45c4c", "#cda18e", "#e2d9e2")
+)
+
+var colormaps = map[string]*Colormap{
+	Grayscale.Name: Grayscale,
+	Viridis.Name:   Viridis,
+	Magma.Name:     Magma,
+	Twilight.Name:  Twilight,
+}
+
+// ColormapNames lists the built in colour maps in alphabetical order
+func ColormapNames() []string {
+	names := make([]string, 0, len(colormaps))
+	for name := range colormaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseColormap looks up a built in colour map by name, or builds a custom gradient
+// from a spec such as "gradient:#000000,#ff8800,#ffffff"
+func ParseColormap(spec string) (*Colormap, error) {
+	if cm, ok := colormaps[spec]; ok {
+		return cm, nil
+	}
+	hexes, ok := strings.CutPrefix(spec, "gradient:")
+	if !ok {
+		return nil, fmt.Errorf("smoothlife: unknown colour map %q", spec)
+	}
+	var stops []color.Color
+	for _, hex := range strings.Split(hexes, ",") {
+		c, err := ParseHexColor(strings.TrimSpace(hex))
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, c)
+	}
+	return NewColormap(spec, stops...)
+}
+
+// ParseHexColor reads an opaque colour written as #rrggbb or #rgb
+func ParseHexColor(hex string) (color.RGBA, error) {
+	digits, ok := strings.CutPrefix(hex, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if !ok || len(digits) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("smoothlife: %q is not a colour like #ff8800", hex)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
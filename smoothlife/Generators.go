@@ -0,0 +1,498 @@
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Generator draws an initial state onto a field. Generators draw on top of what is
+// already there, keeping the larger of the old and new value of every cell, so they
+// can be layered. Shapes wrap around the edges of the field.
+type Generator interface {
+	Generate(field *mat.Dense, rng *rand.Rand)
+}
+
+// Speckles stamps Count squares of side Size at random positions, the original
+// AddSpeckles
+type Speckles struct {
+	Count int
+	Size  float64
+	Value float64
+}
+
+// Generate implements Generator
+func (s Speckles) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	size := int(s.Size)
+	for i := 0; i < s.Count; i++ {
+		row := rng.Intn(max(1, rows-size))
+		col := rng.Intn(max(1, cols-size))
+		for dr := 0; dr < size && row+dr < rows; dr++ {
+			for dc := 0; dc < size && col+dc < cols; dc++ {
+				raise(field, row+dr, col+dc, s.Value)
+			}
+		}
+	}
+}
+
+// UniformNoise sets every cell to a value drawn uniformly from [Min, Max)
+type UniformNoise struct {
+	Min float64
+	Max float64
+}
+
+// Generate implements Generator
+func (u UniformNoise) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			raise(field, i, j, u.Min+(u.Max-u.Min)*rng.Float64())
+		}
+	}
+}
+
+// Validate reports whether the noise stays within [0,1]
+func (u UniformNoise) Validate() error {
+	return checkNoiseRange(u.Min, u.Max)
+}
+
+// GaussianBlobs places Count Gaussian bumps of width Sigma and peak Amplitude at
+// random positions
+type GaussianBlobs struct {
+	Count     int
+	Sigma     float64
+	Amplitude float64
+}
+
+// Generate implements Generator
+func (g GaussianBlobs) Generate(field *mat.Dense, rng *rand.Rand) {
+	extent := g.Sigma * math.Sqrt(2*math.Log(1/kernelTail))
+	for i := 0; i < g.Count; i++ {
+		x, y := randomPoint(field, rng)
+		stamp(field, x, y, extent, func(r float64) float64 {
+			return g.Amplitude * math.Exp(-r*r/(2*g.Sigma*g.Sigma))
+		})
+	}
+}
+
+// Validate reports whether the blobs can be drawn
+func (g GaussianBlobs) Validate() error {
+	if g.Sigma <= 0 {
+		return fmt.Errorf("smoothlife: blob width must be positive, got %v", g.Sigma)
+	}
+	return nil
+}
+
+// Discs places Count antialiased discs at random positions. LogRes sets the
+// sharpness of their edges as in AntialiasedCircle, 0 picks one from the grid size.
+type Discs struct {
+	Count  int
+	Radius float64
+	Value  float64
+	LogRes float64
+}
+
+// Generate implements Generator
+func (d Discs) Generate(field *mat.Dense, rng *rand.Rand) {
+	for i := 0; i < d.Count; i++ {
+		x, y := randomPoint(field, rng)
+		drawDisc(field, x, y, d.Radius, d.Value, d.LogRes)
+	}
+}
+
+// Validate reports whether the discs can be drawn
+func (d Discs) Validate() error {
+	if d.Count <= 0 {
+		return fmt.Errorf("smoothlife: disc count must be positive, got %d", d.Count)
+	}
+	if d.Radius <= 0 {
+		return fmt.Errorf("smoothlife: disc radius must be positive, got %v", d.Radius)
+	}
+	return nil
+}
+
+// Rings places Count antialiased rings between InnerRadius and OuterRadius at
+// random positions
+type Rings struct {
+	Count       int
+	InnerRadius float64
+	OuterRadius float64
+	Value       float64
+	LogRes      float64
+}
+
+// Generate implements Generator
+func (rg Rings) Generate(field *mat.Dense, rng *rand.Rand) {
+	logres := fieldLogRes(field, rg.LogRes)
+	for i := 0; i < rg.Count; i++ {
+		x, y := randomPoint(field, rng)
+		stamp(field, x, y, rg.OuterRadius+math.Log(1/kernelTail)/logres, func(r float64) float64 {
+			return rg.Value * (antialiasedDisc(r, rg.OuterRadius, logres) - antialiasedDisc(r, rg.InnerRadius, logres))
+		})
+	}
+}
+
+// Validate reports whether the rings can be drawn
+func (rg Rings) Validate() error {
+	if rg.InnerRadius < 0 || rg.OuterRadius <= rg.InnerRadius {
+		return fmt.Errorf("smoothlife: ring radii must satisfy 0 <= inner < outer, got %v and %v", rg.InnerRadius, rg.OuterRadius)
+	}
+	return nil
+}
+
+// SparseDiscs scatters antialiased discs with Density discs for every square of
+// side 2*Radius, so the count follows the size of the field
+type SparseDiscs struct {
+	Density float64
+	Radius  float64
+	Value   float64
+	LogRes  float64
+}
+
+// Generate implements Generator
+func (s SparseDiscs) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	count := int(math.Round(s.Density * float64(rows*cols) / math.Pow(2*s.Radius, 2)))
+	Discs{Count: count, Radius: s.Radius, Value: s.Value, LogRes: s.LogRes}.Generate(field, rng)
+}
+
+// Validate reports whether the discs can be drawn
+func (s SparseDiscs) Validate() error {
+	if s.Radius <= 0 {
+		return fmt.Errorf("smoothlife: disc radius must be positive, got %v", s.Radius)
+	}
+	if s.Density < 0 {
+		return fmt.Errorf("smoothlife: disc density must not be negative, got %v", s.Density)
+	}
+	return nil
+}
+
+// CentredBlob draws a single antialiased disc in the middle of the field
+type CentredBlob struct {
+	Radius float64
+	Value  float64
+	LogRes float64
+}
+
+// Generate implements Generator
+func (c CentredBlob) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	drawDisc(field, float64(cols)/2, float64(rows)/2, c.Radius, c.Value, c.LogRes)
+}
+
+// Validate reports whether the blob can be drawn
+func (c CentredBlob) Validate() error {
+	if c.Radius <= 0 {
+		return fmt.Errorf("smoothlife: blob radius must be positive, got %v", c.Radius)
+	}
+	return nil
+}
+
+// ValueNoise is fractal value noise: Octaves layers of smoothly interpolated random
+// values on a lattice that tiles the field, the first with a spacing of Scale cells
+// and each following one at half the spacing and Persistence times the amplitude.
+// The result is scaled into [Min, Max].
+type ValueNoise struct {
+	Scale       float64
+	Octaves     int
+	Persistence float64
+	Min         float64
+	Max         float64
+}
+
+// Generate implements Generator
+func (v ValueNoise) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	noise := make([]float64, rows*cols)
+	amplitude, total := 1.0, 0.0
+	spacing := v.Scale
+	for o := 0; o < v.Octaves; o++ {
+		// Round the lattice to the field so that it tiles
+		latticeX := max(1, int(math.Round(float64(cols)/spacing)))
+		latticeY := max(1, int(math.Round(float64(rows)/spacing)))
+		lattice := make([]float64, latticeX*latticeY)
+		for i := range lattice {
+			lattice[i] = rng.Float64()
+		}
+		at := func(i, j int) float64 {
+			return lattice[(i%latticeY)*latticeX+j%latticeX]
+		}
+		for i := 0; i < rows; i++ {
+			y := float64(i) * float64(latticeY) / float64(rows)
+			y0 := int(y)
+			ty := smoothstep(y - float64(y0))
+			for j := 0; j < cols; j++ {
+				x := float64(j) * float64(latticeX) / float64(cols)
+				x0 := int(x)
+				tx := smoothstep(x - float64(x0))
+				top := Lerp(at(y0, x0), at(y0, x0+1), tx)
+				bottom := Lerp(at(y0+1, x0), at(y0+1, x0+1), tx)
+				noise[i*cols+j] += amplitude * Lerp(top, bottom, ty)
+			}
+		}
+		total += amplitude
+		amplitude *= v.Persistence
+		spacing /= 2
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			raise(field, i, j, v.Min+(v.Max-v.Min)*noise[i*cols+j]/total)
+		}
+	}
+}
+
+// Validate reports whether the noise can be drawn
+func (v ValueNoise) Validate() error {
+	if v.Scale <= 0 {
+		return fmt.Errorf("smoothlife: noise scale must be positive, got %v", v.Scale)
+	}
+	if v.Octaves < 1 {
+		return fmt.Errorf("smoothlife: noise needs at least one octave, got %d", v.Octaves)
+	}
+	if v.Persistence < 0 {
+		return fmt.Errorf("smoothlife: noise persistence must not be negative, got %v", v.Persistence)
+	}
+	return checkNoiseRange(v.Min, v.Max)
+}
+
+// checkNoiseRange requires 0 <= min <= max <= 1, so that noise between them is a
+// valid state
+func checkNoiseRange(min float64, max float64) error {
+	if !(0 <= min && min <= max && max <= 1) {
+		return fmt.Errorf("smoothlife: noise range must satisfy 0 <= min <= max <= 1, got [%v, %v]", min, max)
+	}
+	return nil
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// raise sets a cell to value if that is larger than what it holds
+func raise(field *mat.Dense, i int, j int, value float64) {
+	if value > field.At(i, j) {
+		field.Set(i, j, value)
+	}
+}
+
+func randomPoint(field *mat.Dense, rng *rand.Rand) (x float64, y float64) {
+	rows, cols := field.Dims()
+	return rng.Float64() * float64(cols), rng.Float64() * float64(rows)
+}
+
+// fieldLogRes resolves a LogRes of 0 the way AntialiasedCircle does
+func fieldLogRes(field *mat.Dense, logres float64) float64 {
+	if logres != 0 {
+		return logres
+	}
+	rows, cols := field.Dims()
+	return math.Log2(math.Min(float64(rows), float64(cols)))
+}
+
+// antialiasedDisc is the profile of AntialiasedCircle at distance r from the centre
+func antialiasedDisc(r float64, radius float64, logres float64) float64 {
+	return 1 / (1 + math.Exp(logres*(r-radius)))
+}
+
+func drawDisc(field *mat.Dense, x float64, y float64, radius float64, value float64, logres float64) {
+	logres = fieldLogRes(field, logres)
+	stamp(field, x, y, radius+math.Log(1/kernelTail)/logres, func(r float64) float64 {
+		return value * antialiasedDisc(r, radius, logres)
+	})
+}
+
+// stamp raises the cells within extent of (x, y) to profile(r), r being their
+// distance from it, wrapping around the edges of the field
+func stamp(field *mat.Dense, x float64, y float64, extent float64, profile func(r float64) float64) {
+	visit(field, x, y, extent, func(i, j int, r float64) {
+		raise(field, i, j, profile(r))
+	})
+}
+
+// visit calls fn once with every cell within extent of (x, y) and its distance r
+// from it, wrapping around the edges of the field. A cell the extent reaches more
+// than once is visited at its nearest image.
+func visit(field *mat.Dense, x float64, y float64, extent float64, fn func(i, j int, r float64)) {
+	rows, cols := field.Dims()
+	top, bottom := span(y, extent, rows)
+	left, right := span(x, extent, cols)
+	for i := top; i <= bottom; i++ {
+		for j := left; j <= right; j++ {
+			r := math.Hypot(float64(j)-x, float64(i)-y)
+			if r > extent {
+				continue
+			}
+			fn(wrap(i, rows), wrap(j, cols), r)
+		}
+	}
+}
+
+// span returns the first and last offsets within extent of c along an axis of size
+// cells, at most one period of them centred on c
+func span(c float64, extent float64, size int) (int, int) {
+	first, last := int(math.Floor(c-extent)), int(math.Ceil(c+extent))
+	if last-first+1 > size {
+		first = int(math.Floor(c-float64(size)/2)) + 1
+		last = first + size - 1
+	}
+	return first, last
+}
+
+func wrap(i int, size int) int {
+	i %= size
+	if i < 0 {
+		i += size
+	}
+	return i
+}
+
+var (
+	generatorRegistryMu sync.RWMutex
+	// Generators are built for a simulation whose kernel has the given outer radius,
+	// which sets the default size of the shapes
+	generatorRegistry = map[string]func(radius float64) Generator{
+		"speckles": func(r float64) Generator { return &Speckles{Count: 25, Size: r, Value: 1} },
+		"noise":    func(r float64) Generator { return &UniformNoise{Min: 0, Max: 1} },
+		"blobs":    func(r float64) Generator { return &GaussianBlobs{Count: 10, Sigma: r / 2, Amplitude: 1} },
+		"discs":    func(r float64) Generator { return &Discs{Count: 10, Radius: r / 2, Value: 1} },
+		"rings":    func(r float64) Generator { return &Rings{Count: 10, InnerRadius: r / 4, OuterRadius: r / 2, Value: 1} },
+		"sparse":   func(r float64) Generator { return &SparseDiscs{Density: 0.25, Radius: r / 2, Value: 1} },
+		"blob":     func(r float64) Generator { return &CentredBlob{Radius: r, Value: 1} },
+		"value-noise": func(r float64) Generator {
+			return &ValueNoise{Scale: 2 * r, Octaves: 4, Persistence: 0.5, Min: 0, Max: 1}
+		},
+	}
+)
+
+// RegisterGenerator makes a generator available under name. newGenerator must
+// return a fresh pointer sized for a kernel of outer radius radius.
+// Registering a name twice panics.
+func RegisterGenerator(name string, newGenerator func(radius float64) Generator) {
+	generatorRegistryMu.Lock()
+	defer generatorRegistryMu.Unlock()
+	if _, ok := generatorRegistry[name]; ok {
+		panic(fmt.Sprintf("smoothlife: generator %q registered twice", name))
+	}
+	generatorRegistry[name] = newGenerator
+}
+
+// NewGenerator returns a fresh generator registered under name, with default
+// parameters for a kernel of outer radius radius
+func NewGenerator(name string, radius float64) (Generator, error) {
+	generatorRegistryMu.RLock()
+	defer generatorRegistryMu.RUnlock()
+	newGenerator, ok := generatorRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("smoothlife: unknown generator %q", name)
+	}
+	return newGenerator(radius), nil
+}
+
+// GeneratorNames lists the registered generators in alphabetical order
+func GeneratorNames() []string {
+	generatorRegistryMu.RLock()
+	defer generatorRegistryMu.RUnlock()
+	names := make([]string, 0, len(generatorRegistry))
+	for name := range generatorRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseGenerator builds a generator from a spec such as "discs" or
+// "discs:count=40,radius=6". The parameters set the fields of the generator by
+// name, ignoring case, and the others keep the defaults of NewGenerator.
+func ParseGenerator(spec string, radius float64) (Generator, error) {
+	name, params, _ := strings.Cut(spec, ":")
+	g, err := NewGenerator(name, radius)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(g)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		if params != "" {
+			return nil, fmt.Errorf("smoothlife: generator %q takes no parameters", name)
+		}
+		return checkedGenerator(g)
+	}
+	v = v.Elem()
+	for _, param := range strings.Split(params, ",") {
+		if param == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("smoothlife: generator parameter %q is not key=value", param)
+		}
+		field := v.FieldByNameFunc(func(f string) bool { return strings.EqualFold(f, strings.TrimSpace(key)) })
+		if !field.IsValid() || !field.CanSet() {
+			return nil, fmt.Errorf("smoothlife: generator %q has no parameter %q", name, key)
+		}
+		switch field.Kind() {
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("smoothlife: generator parameter %s: %w", key, err)
+			}
+			field.SetFloat(f)
+		case reflect.Int:
+			i, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("smoothlife: generator parameter %s: %w", key, err)
+			}
+			field.SetInt(int64(i))
+		default:
+			return nil, fmt.Errorf("smoothlife: generator parameter %s cannot be set from a string", key)
+		}
+	}
+	return checkedGenerator(g)
+}
+
+func checkedGenerator(g Generator) (Generator, error) {
+	if err := validateGenerator(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// validateGenerator calls the Validate method of generators that have one
+func validateGenerator(g Generator) error {
+	if g == nil {
+		return errors.New("smoothlife: a generator is required")
+	}
+	if v, ok := g.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Generate draws g onto every channel, drawing from the random source of the
+// simulation
+func (sl *SmoothLife) Generate(g Generator) error {
+	if err := validateGenerator(g); err != nil {
+		return err
+	}
+	for _, field := range sl.field {
+		g.Generate(field, sl.rng)
+	}
+	return nil
+}
+
+// GenerateChannel draws g onto channel c
+func (sl *SmoothLife) GenerateChannel(c int, g Generator) error {
+	if err := validateGenerator(g); err != nil {
+		return err
+	}
+	g.Generate(sl.field[c], sl.rng)
+	return nil
+}
@@ -0,0 +1,250 @@
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Resample selects the filter used to scale an image onto the field
+type Resample int
+
+const (
+	// ResampleNearest picks the closest pixel
+	ResampleNearest Resample = iota
+	// ResampleBox averages the pixels each cell covers
+	ResampleBox
+	// ResampleBilinear interpolates linearly between neighbouring pixels
+	ResampleBilinear
+	// ResampleBicubic is the Catmull-Rom cubic, the sharpest of the filters
+	ResampleBicubic
+)
+
+var resampleNames = map[Resample]string{
+	ResampleNearest:  "nearest",
+	ResampleBox:      "box",
+	ResampleBilinear: "bilinear",
+	ResampleBicubic:  "bicubic",
+}
+
+func (r Resample) String() string {
+	if name, ok := resampleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Resample(%d)", int(r))
+}
+
+// ParseResample looks up a filter by the name String returns
+func ParseResample(name string) (Resample, error) {
+	for r, n := range resampleNames {
+		if n == name {
+			return r, nil
+		}
+	}
+	return ResampleNearest, fmt.Errorf("smoothlife: unknown resampling filter %q", name)
+}
+
+// kernel returns the support and weight function of the filter, in source pixels
+// when enlarging
+func (r Resample) kernel() (float64, func(x float64) float64) {
+	switch r {
+	case ResampleBilinear:
+		return 1, func(x float64) float64 { return 1 - math.Abs(x) }
+	case ResampleBicubic:
+		return 2, func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return (3*x*x*x - 5*x*x + 2) / 2
+			}
+			return (-x*x*x + 5*x*x - 8*x + 4) / 2
+		}
+	default:
+		return 0.5, func(x float64) float64 { return 1 }
+	}
+}
+
+// Fit selects how an image of a different size is mapped onto the field
+type Fit int
+
+const (
+	// FitStretch scales the image to the size of the field, ignoring its aspect ratio
+	FitStretch Fit = iota
+	// FitCover scales the image to cover the field and crops what overflows
+	FitCover
+	// FitContain scales the image to fit inside the field
+	FitContain
+	// FitCrop centres the image unscaled and crops what overflows
+	FitCrop
+)
+
+var fitNames = map[Fit]string{
+	FitStretch: "stretch",
+	FitCover:   "cover",
+	FitContain: "contain",
+	FitCrop:    "crop",
+}
+
+func (f Fit) String() string {
+	if name, ok := fitNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Fit(%d)", int(f))
+}
+
+// ParseFit looks up a fit by the name String returns
+func ParseFit(name string) (Fit, error) {
+	for f, n := range fitNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return FitStretch, fmt.Errorf("smoothlife: unknown fit %q", name)
+}
+
+// DecodeImage reads a PNG, JPEG or GIF image
+func DecodeImage(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("smoothlife: decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// ImageSeed is a Generator drawing an image onto the field. The image is converted
+// to grayscale in [0,1], transparent pixels count as black, then fitted to the
+// field with Filter. Cells the image does not reach are left alone.
+type ImageSeed struct {
+	Image  image.Image
+	Fit    Fit
+	Filter Resample
+	// Invert maps v to 1-v, so that dark pixels come alive
+	Invert bool
+	// Threshold, when positive, maps cells at or above it to 1 and the rest to 0
+	Threshold float64
+}
+
+// Generate implements Generator
+func (is ImageSeed) Generate(field *mat.Dense, rng *rand.Rand) {
+	rows, cols := field.Dims()
+	gray, width, height := grayscale(is.Image)
+
+	scaledWidth, scaledHeight := width, height
+	scaleX := float64(cols) / float64(width)
+	scaleY := float64(rows) / float64(height)
+	switch is.Fit {
+	case FitStretch:
+		scaledWidth, scaledHeight = cols, rows
+	case FitCover, FitContain:
+		scale := math.Max(scaleX, scaleY)
+		if is.Fit == FitContain {
+			scale = math.Min(scaleX, scaleY)
+		}
+		scaledWidth = max(1, int(math.Round(float64(width)*scale)))
+		scaledHeight = max(1, int(math.Round(float64(height)*scale)))
+	}
+	scaled := resample(gray, width, height, scaledWidth, scaledHeight, is.Filter)
+
+	// Centre the scaled image on the field
+	offsetX := (cols - scaledWidth) / 2
+	offsetY := (rows - scaledHeight) / 2
+	for i := max(0, offsetY); i < min(rows, offsetY+scaledHeight); i++ {
+		for j := max(0, offsetX); j < min(cols, offsetX+scaledWidth); j++ {
+			v := Clamp(scaled[(i-offsetY)*scaledWidth+j-offsetX], 0, 1)
+			if is.Invert {
+				v = 1 - v
+			}
+			if is.Threshold > 0 {
+				if v >= is.Threshold {
+					v = 1
+				} else {
+					v = 0
+				}
+			}
+			raise(field, i, j, v)
+		}
+	}
+}
+
+// Validate reports whether the image can be drawn
+func (is ImageSeed) Validate() error {
+	if is.Image == nil || is.Image.Bounds().Empty() {
+		return errors.New("smoothlife: an image is required")
+	}
+	if _, ok := fitNames[is.Fit]; !ok {
+		return fmt.Errorf("smoothlife: unknown fit %v", is.Fit)
+	}
+	if _, ok := resampleNames[is.Filter]; !ok {
+		return fmt.Errorf("smoothlife: unknown resampling filter %v", is.Filter)
+	}
+	if is.Threshold > 1 {
+		return fmt.Errorf("smoothlife: image threshold must be at most 1, got %v", is.Threshold)
+	}
+	return nil
+}
+
+// grayscale returns the luminance of img in [0,1], row by row
+func grayscale(img image.Image) ([]float64, int, int) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	gray := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g := color.Gray16Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16)
+			gray[y*width+x] = float64(g.Y) / 0xffff
+		}
+	}
+	return gray, width, height
+}
+
+// resample scales a width by height grid to the new size, one axis at a time
+func resample(src []float64, width int, height int, newWidth int, newHeight int, filter Resample) []float64 {
+	if width == newWidth && height == newHeight {
+		return src
+	}
+	// Rows first, then columns
+	tmp := make([]float64, newWidth*height)
+	for y := 0; y < height; y++ {
+		resampleLine(tmp[y*newWidth:(y+1)*newWidth], 1, src[y*width:(y+1)*width], 1, width, filter)
+	}
+	dst := make([]float64, newWidth*newHeight)
+	for x := 0; x < newWidth; x++ {
+		resampleLine(dst[x:], newWidth, tmp[x:], newWidth, height, filter)
+	}
+	return dst
+}
+
+// resampleLine scales the n values of src, stride apart, into the values of dst.
+// When shrinking, the filter is widened so that every source pixel contributes.
+func resampleLine(dst []float64, dstStride int, src []float64, srcStride int, n int, filter Resample) {
+	count := (len(dst) + dstStride - 1) / dstStride
+	scale := float64(n) / float64(count)
+	if filter == ResampleNearest {
+		for i := 0; i < count; i++ {
+			j := min(n-1, int((float64(i)+0.5)*scale))
+			dst[i*dstStride] = src[j*srcStride]
+		}
+		return
+	}
+	support, weight := filter.kernel()
+	filterScale := math.Max(scale, 1)
+	support *= filterScale
+	for i := 0; i < count; i++ {
+		centre := (float64(i)+0.5)*scale - 0.5
+		var sum, total float64
+		for j := int(math.Ceil(centre - support)); j <= int(math.Floor(centre+support)); j++ {
+			w := weight((float64(j) - centre) / filterScale)
+			sum += w * src[min(n-1, max(0, j))*srcStride]
+			total += w
+		}
+		dst[i*dstStride] = sum / total
+	}
+}
@@ -0,0 +1,135 @@
+package smoothlife
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Layer is a buffer of the update that can be inspected
+type Layer int
+
+const (
+	// LayerField is the field itself
+	LayerField Layer = iota
+	// LayerM is the inner density m
+	LayerM
+	// LayerN is the outer (annulus) density n
+	LayerN
+	// LayerAliveness is how alive the basic rules judge each cell from m
+	LayerAliveness
+	// LayerThreshold1 is the lower bound of the interval n must fall in, mixed from B1
+	// and D1 by the aliveness
+	LayerThreshold1
+	// LayerThreshold2 is the upper bound, mixed from B2 and D2
+	LayerThreshold2
+	// LayerDeltaS is the change the rule would make to each cell
+	LayerDeltaS
+	// LayerInnerKernel is the kernel measuring m, centred on the field
+	LayerInnerKernel
+	// LayerAnnulusKernel is the kernel measuring n, centred on the field
+	LayerAnnulusKernel
+)
+
+var layerNames = map[Layer]string{
+	LayerField:         "field",
+	LayerM:             "m",
+	LayerN:             "n",
+	LayerAliveness:     "aliveness",
+	LayerThreshold1:    "threshold1",
+	LayerThreshold2:    "threshold2",
+	LayerDeltaS:        "delta-s",
+	LayerInnerKernel:   "inner-kernel",
+	LayerAnnulusKernel: "annulus-kernel",
+}
+
+func (layer Layer) String() string {
+	if name, ok := layerNames[layer]; ok {
+		return name
+	}
+	return fmt.Sprintf("Layer(%d)", int(layer))
+}
+
+// ParseLayer looks up a layer by the name String returns
+func ParseLayer(name string) (Layer, error) {
+	for layer, n := range layerNames {
+		if n == name {
+			return layer, nil
+		}
+	}
+	return LayerField, fmt.Errorf("smoothlife: unknown layer %q", name)
+}
+
+// Inspect writes a layer of channel c into dst, which must be the size of the field.
+// The densities and everything derived from them are measured on the current field,
+// as the next step would. For the kernel layers c selects the kernel instead.
+// Aliveness and the thresholds are only defined for BasicRules.
+func (sl *SmoothLife) Inspect(layer Layer, c int, dst *mat.Dense) error {
+	switch layer {
+	case LayerField:
+		dst.Copy(sl.field[c])
+		return nil
+	case LayerInnerKernel, LayerAnnulusKernel:
+		if c < 0 || c >= len(sl.kernels) {
+			return fmt.Errorf("smoothlife: no kernel %d", c)
+		}
+		kernel := sl.kernels[c].inner
+		if layer == LayerAnnulusKernel {
+			kernel = sl.kernels[c].annulus
+		}
+		centreKernel(dst, kernel)
+		return nil
+	}
+	if _, ok := layerNames[layer]; !ok {
+		return fmt.Errorf("smoothlife: unknown layer %v", layer)
+	}
+
+	sl.convolve(sl.field)
+	n, m := sl.ws.n[c], sl.ws.m[c]
+	switch layer {
+	case LayerM:
+		dst.Copy(m)
+	case LayerN:
+		dst.Copy(n)
+	case LayerDeltaS:
+		sl.rules[c].Apply(dst, n, m, sl.field[c])
+		dst.Sub(dst, sl.field[c])
+	default:
+		var br BasicRules
+		switch rule := sl.rules[c].(type) {
+		case BasicRules:
+			br = rule
+		case *BasicRules:
+			br = *rule
+		default:
+			return fmt.Errorf("smoothlife: %v is only defined for BasicRules, channel %d uses %T", layer, c, rule)
+		}
+		rows, cols := dst.Dims()
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				stages := br.stages(n.At(i, j), m.At(i, j))
+				switch layer {
+				case LayerAliveness:
+					dst.Set(i, j, stages.aliveness)
+				case LayerThreshold1:
+					dst.Set(i, j, stages.threshold1)
+				case LayerThreshold2:
+					dst.Set(i, j, stages.threshold2)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// centreKernel copies a kernel stored with its centre at the origin into dst with
+// its centre in the middle, cropping or wrapping it to the size of dst
+func centreKernel(dst *mat.Dense, kernel *mat.Dense) {
+	rows, cols := dst.Dims()
+	kernelRows, kernelCols := kernel.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			dst.Set(i, j, kernel.At(wrap(i-rows/2, kernelRows), wrap(j-cols/2, kernelCols)))
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// KernelCore is the radial profile of a single Lenia kernel shell
+type KernelCore int
+
+const (
+	// CoreExponential is the bump exp(4 - 1/(r(1-r)))
+	CoreExponential KernelCore = iota
+	// CorePolynomial is the bump (4r(1-r))^4
+	CorePolynomial
+)
+
+var kernelCoreNames = map[KernelCore]string{
+	CoreExponential: "exponential",
+	CorePolynomial:  "polynomial",
+}
+
+func (core KernelCore) String() string {
+	if name, ok := kernelCoreNames[core]; ok {
+		return name
+	}
+	return fmt.Sprintf("KernelCore(%d)", int(core))
+}
+
+// at evaluates the core at r in [0,1]
+func (core KernelCore) at(r float64) float64 {
+	if r <= 0 || r >= 1 {
+		return 0
+	}
+	switch core {
+	case CorePolynomial:
+		return math.Pow(4*r*(1-r), 4)
+	default:
+		return math.Exp(4 - 1/(r*(1-r)))
+	}
+}
+
+// GrowthFunc maps the kernel density onto a growth rate in [-1,1]
+type GrowthFunc int
+
+const (
+	// GrowthGaussian is 2exp(-(u-mu)^2 / 2sigma^2) - 1
+	GrowthGaussian GrowthFunc = iota
+	// GrowthPolynomial is 2max(0, 1-(u-mu)^2 / 9sigma^2)^4 - 1
+	GrowthPolynomial
+)
+
+var growthFuncNames = map[GrowthFunc]string{
+	GrowthGaussian:   "gaussian",
+	GrowthPolynomial: "polynomial",
+}
+
+func (growth GrowthFunc) String() string {
+	if name, ok := growthFuncNames[growth]; ok {
+		return name
+	}
+	return fmt.Sprintf("GrowthFunc(%d)", int(growth))
+}
+
+// at evaluates the growth function for a density u
+func (growth GrowthFunc) at(u float64, mu float64, sigma float64) float64 {
+	d := u - mu
+	switch growth {
+	case GrowthPolynomial:
+		return 2*math.Pow(math.Max(0, 1-d*d/(9*sigma*sigma)), 4) - 1
+	default:
+		return 2*math.Exp(-d*d/(2*sigma*sigma)) - 1
+	}
+}
+
+// LeniaKernel is a ring kernel made of len(B) concentric shells of equal width,
+// shell i peaking at weight B[i]
+type LeniaKernel struct {
+	// R is the outer radius of the kernel in cells
+	R    float64
+	B    []float64
+	Core KernelCore
+}
+
+// Validate reports whether the kernel can be built
+func (k LeniaKernel) Validate() error {
+	if k.R <= 0 {
+		return fmt.Errorf("smoothlife: lenia kernel radius must be positive, got %v", k.R)
+	}
+	if len(k.B) == 0 {
+		return errors.New("smoothlife: lenia kernel needs at least one shell weight")
+	}
+	if _, ok := kernelCoreNames[k.Core]; !ok {
+		return fmt.Errorf("smoothlife: unknown kernel core %v", k.Core)
+	}
+	for _, b := range k.B {
+		if b < 0 {
+			return fmt.Errorf("smoothlife: lenia shell weights must not be negative, got %v", k.B)
+		}
+	}
+	return nil
+}
+
+// Weights samples the kernel on a sizeX by sizeY grid, centred on the origin and
+// wrapped around the edges like AntialiasedCircle with roll set, then scaled so the
+// weights sum to 1
+func (k LeniaKernel) Weights(sizeX int, sizeY int) *mat.Dense {
+	halfX := float64(sizeX) / 2
+	halfY := float64(sizeY) / 2
+	shells := float64(len(k.B))
+
+	weights := mat.NewDense(sizeY, sizeX, nil)
+	for i := 0; i < sizeY; i++ {
+		for j := 0; j < sizeX; j++ {
+			x := float64(j) - halfX
+			y := float64(i) - halfY
+			r := math.Sqrt(x*x+y*y) / k.R
+			if r >= 1 {
+				continue
+			}
+			shell := math.Floor(r * shells)
+			weights.Set(i, j, k.B[int(shell)]*k.Core.at(r*shells-shell))
+		}
+	}
+	weights = RollMatrix(weights, sizeY/2, sizeX/2)
+	return DivideDenseMatrix(weights, SumDenseMatrix(weights))
+}
+
+// ConstructLeniaMultipliers precomputes the FFT of a Lenia kernel. Lenia only
+// measures a single density, so the kernel is used for both m and n and the
+// simulation convolves it once.
+func ConstructLeniaMultipliers(kernel LeniaKernel, width int, height int) *Multipliers {
+	weights := kernel.Weights(width, height)
+	K := rfft2dense(weights)
+	return &Multipliers{
+		inner:       weights,
+		outer:       weights,
+		outerRadius: kernel.R,
+		annulus:     weights,
+		M:           K,
+		N:           K,
+	}
+}
+
+// LeniaRules is the Lenia update A' = clip(A + G(K*A)/T, 0, 1). The kernel density
+// K*A arrives as n. It builds on the current field, so it is meant to be used with
+// the Discrete time step mode.
+type LeniaRules struct {
+	Mu     float64
+	Sigma  float64
+	Growth GrowthFunc
+	// T is the time resolution, the number of steps per unit of time
+	T float64
+}
+
+// Apply implements Rule
+func (lr LeniaRules) Apply(dst, n, m, field *mat.Dense) {
+	dt := 1 / lr.T
+	rows, cols := dst.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			growth := lr.Growth.at(n.At(i, j), lr.Mu, lr.Sigma)
+			dst.Set(i, j, Clamp(field.At(i, j)+dt*growth, 0, 1))
+		}
+	}
+}
+
+// Validate reports whether the growth parameters are usable
+func (lr LeniaRules) Validate() error {
+	if lr.T <= 0 {
+		return fmt.Errorf("smoothlife: lenia time resolution must be positive, got %v", lr.T)
+	}
+	if lr.Sigma <= 0 {
+		return fmt.Errorf("smoothlife: lenia growth width must be positive, got %v", lr.Sigma)
+	}
+	if _, ok := growthFuncNames[lr.Growth]; !ok {
+		return fmt.Errorf("smoothlife: unknown growth function %v", lr.Growth)
+	}
+	return nil
+}
+
+// LeniaOptions returns the parameters of Orbium, the best known Lenia glider
+func LeniaOptions() Options {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 1<<8, 1<<8
+	opts.Lenia = &LeniaKernel{R: 13, B: []float64{1}, Core: CoreExponential}
+	opts.Rule = LeniaRules{Mu: 0.15, Sigma: 0.015, Growth: GrowthGaussian, T: 10}
+	opts.TimeStep = TimeStep{Mode: Discrete}
+	return opts
+}
@@ -1,4 +1,4 @@
-package main
+package smoothlife
 
 import (
 	"gonum.org/v1/gonum/mat"
@@ -9,8 +9,9 @@ type Multipliers struct {
 	outer       *mat.Dense
 	outerRadius float64
 	annulus     *mat.Dense
-	M           *mat.CDense
-	N           *mat.CDense
+	// M and N are the half spectra of the inner disc and the annulus, see rfft2
+	M *mat.CDense
+	N *mat.CDense
 }
 
 func ConstructMultipliers(
@@ -22,11 +23,8 @@ func ConstructMultipliers(
 ) *Multipliers {
 	inner := AntialiasedCircle(width, height, innerRadius, true, logres)
 	outer := AntialiasedCircle(width, height, outerRadius, true, logres)
-	// saveMatrixAsImage(inner, "inner.png")
-	// saveMatrixAsImage(outer, "outer.png")
 	annulus := mat.NewDense(height, width, nil)
 	annulus.Sub(outer, inner)
-	// saveMatrixAsImage(annulus, "annulus.png")
 
 	// Scale each kernel so the sum is 1
 	inner_magnitude := SumDenseMatrix(inner)
@@ -34,13 +32,11 @@ func ConstructMultipliers(
 	annulus_magnitude := SumDenseMatrix(annulus)
 
 	inner = DivideDenseMatrix(inner, inner_magnitude)
-	// saveMatrixAsImage(inner, "inner_scaled.png")
 	annulus = DivideDenseMatrix(annulus, annulus_magnitude)
-	// saveMatrixAsImage(annulus, "annulus_scaled.png")
 
 	// Precompute the FFT's
-	M := fft2dense(inner)
-	N := fft2dense(annulus)
+	M := rfft2dense(inner)
+	N := rfft2dense(annulus)
 
 	return &Multipliers{
 		inner:       inner,
@@ -51,3 +47,8 @@ func ConstructMultipliers(
 		N:           N,
 	}
 }
+
+// OuterRadius is the radius of the neighbourhood the kernel measures
+func (mp *Multipliers) OuterRadius() float64 {
+	return mp.outerRadius
+}
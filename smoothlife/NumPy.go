@@ -0,0 +1,372 @@
+package smoothlife
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NumPy files are a magic string, a format version, a little endian header length
+// and a Python dict literal describing the array, followed by the raw data
+const (
+	npyMagic = "\x93NUMPY"
+	// npyAlign is the alignment of the data that follows the header
+	npyAlign = 64
+)
+
+// WriteNPY writes m to w as a C ordered little endian float64 .npy array, the
+// format np.save uses for a float64 array of the same shape
+func WriteNPY(w io.Writer, m *mat.Dense) error {
+	rows, cols := m.Dims()
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%d, %d), }", rows, cols)
+	// Pad with spaces so that the data is aligned, the header ends with a newline
+	prefix := len(npyMagic) + 2 + 2
+	padding := npyAlign - (prefix+len(header)+1)%npyAlign
+	if padding == npyAlign {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	start := append([]byte(npyMagic), 1, 0)
+	start = binary.LittleEndian.AppendUint16(start, uint16(len(header)))
+	start = append(start, header...)
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(start); err != nil {
+		return err
+	}
+	var buf [8]byte
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(m.At(i, j)))
+			if _, err := bw.Write(buf[:]); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadNPY reads a .npy array of float64 or float32 values in either byte order and
+// either memory order. One dimensional arrays become a single row and scalars a
+// 1x1 matrix.
+func ReadNPY(r io.Reader) (*mat.Dense, error) {
+	br := bufio.NewReader(r)
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(br, prefix); err != nil {
+		return nil, fmt.Errorf("smoothlife: reading npy: %w", err)
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("smoothlife: not an npy file")
+	}
+	var headerLen int
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("smoothlife: reading npy: %w", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("smoothlife: reading npy: %w", err)
+		}
+		if n > 1<<20 {
+			return nil, fmt.Errorf("smoothlife: npy header of %d bytes is too large", n)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("smoothlife: unsupported npy version %d", major)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("smoothlife: reading npy: %w", err)
+	}
+	h, err := parseNPYHeader(string(header))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, cols := h.rows, h.cols
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("smoothlife: cannot read an empty %dx%d npy array", rows, cols)
+	}
+	if rows > maxSnapshotCells || cols > maxSnapshotCells || rows*cols > maxSnapshotCells {
+		return nil, fmt.Errorf("smoothlife: npy array of %dx%d is too large", rows, cols)
+	}
+	raw := make([]byte, rows*cols*h.size)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return nil, fmt.Errorf("smoothlife: reading npy data: %w", err)
+	}
+	data := make([]float64, rows*cols)
+	for i := range data {
+		if h.size == 8 {
+			data[i] = math.Float64frombits(h.order.Uint64(raw[i*8:]))
+		} else {
+			data[i] = float64(math.Float32frombits(h.order.Uint32(raw[i*4:])))
+		}
+	}
+	if h.fortran {
+		// Column major, read it as the transpose and copy it back
+		t := mat.NewDense(cols, rows, data)
+		return mat.DenseCopyOf(t.T()), nil
+	}
+	return mat.NewDense(rows, cols, data), nil
+}
+
+// npyHeader is what ReadNPY needs from the header dict
+type npyHeader struct {
+	order   binary.ByteOrder
+	size    int
+	fortran bool
+	rows    int
+	cols    int
+}
+
+// parseNPYHeader parses the dict literal np.save writes, for instance
+// {'descr': '<f8', 'fortran_order': False, 'shape': (3, 4), }
+func parseNPYHeader(header string) (npyHeader, error) {
+	h := npyHeader{}
+	value := func(key string) (string, error) {
+		i := strings.Index(header, "'"+key+"':")
+		if i < 0 {
+			return "", fmt.Errorf("smoothlife: npy header has no %s", key)
+		}
+		return strings.TrimSpace(header[i+len(key)+3:]), nil
+	}
+
+	descr, err := value("descr")
+	if err != nil {
+		return h, err
+	}
+	switch {
+	case strings.HasPrefix(descr, "'<f8'"):
+		h.order, h.size = binary.LittleEndian, 8
+	case strings.HasPrefix(descr, "'>f8'"):
+		h.order, h.size = binary.BigEndian, 8
+	case strings.HasPrefix(descr, "'<f4'"):
+		h.order, h.size = binary.LittleEndian, 4
+	case strings.HasPrefix(descr, "'>f4'"):
+		h.order, h.size = binary.BigEndian, 4
+	default:
+		end := strings.IndexAny(descr, ",}")
+		if end < 0 {
+			end = len(descr)
+		}
+		return h, fmt.Errorf("smoothlife: unsupported npy dtype %s", descr[:end])
+	}
+
+	fortran, err := value("fortran_order")
+	if err != nil {
+		return h, err
+	}
+	h.fortran = strings.HasPrefix(fortran, "True")
+
+	shape, err := value("shape")
+	if err != nil {
+		return h, err
+	}
+	end := strings.Index(shape, ")")
+	if !strings.HasPrefix(shape, "(") || end < 0 {
+		return h, fmt.Errorf("smoothlife: malformed npy shape %q", shape)
+	}
+	var dims []int
+	for _, field := range strings.Split(shape[1:end], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		d, err := strconv.Atoi(field)
+		if err != nil || d < 0 {
+			return h, fmt.Errorf("smoothlife: malformed npy shape %q", shape[:end+1])
+		}
+		dims = append(dims, d)
+	}
+	switch len(dims) {
+	case 0:
+		h.rows, h.cols = 1, 1
+	case 1:
+		h.rows, h.cols = 1, dims[0]
+	case 2:
+		h.rows, h.cols = dims[0], dims[1]
+	default:
+		return h, fmt.Errorf("smoothlife: cannot read a %d dimensional npy array", len(dims))
+	}
+	return h, nil
+}
+
+// WriteNPZ writes arrays to w as an uncompressed .npz archive, like np.savez.
+// The arrays are stored in alphabetical order of their names.
+func WriteNPZ(w io.Writer, arrays map[string]*mat.Dense) error {
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if err := WriteNPY(f, arrays[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ReadNPZ reads every array of an .npz archive, compressed or not, keyed by name
+// without the .npy extension
+func ReadNPZ(r io.ReaderAt, size int64) (map[string]*mat.Dense, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("smoothlife: reading npz: %w", err)
+	}
+	arrays := make(map[string]*mat.Dense, len(zr.File))
+	for _, f := range zr.File {
+		name, ok := strings.CutSuffix(f.Name, ".npy")
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("smoothlife: reading npz: %w", err)
+		}
+		array, err := ReadNPY(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w in %s", err, f.Name)
+		}
+		arrays[name] = array
+	}
+	return arrays, nil
+}
+
+// arrayName numbers name when there is more than one of it
+func arrayName(name string, i int, count int) string {
+	if count == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s_%d", name, i)
+}
+
+// Arrays returns copies of the state of the simulation, named the way the Python
+// SmoothLife names them: the field, the inner and annulus kernel of every
+// Multipliers, and the densities n and m the next step would measure. Names get
+// a _0, _1, ... suffix when there are several channels or kernels. Kernels are
+// sampled on the padded grid when the boundary is not periodic.
+func (s *Simulation) Arrays() map[string]*mat.Dense {
+	s.convolve(s.field)
+	arrays := map[string]*mat.Dense{}
+	for c := range s.field {
+		arrays[arrayName("field", c, len(s.field))] = mat.DenseCopyOf(s.field[c])
+		arrays[arrayName("n", c, len(s.field))] = mat.DenseCopyOf(s.ws.n[c])
+		arrays[arrayName("m", c, len(s.field))] = mat.DenseCopyOf(s.ws.m[c])
+	}
+	for k, mp := range s.kernels {
+		arrays[arrayName("inner", k, len(s.kernels))] = mat.DenseCopyOf(mp.inner)
+		arrays[arrayName("annulus", k, len(s.kernels))] = mat.DenseCopyOf(mp.annulus)
+	}
+	return arrays
+}
+
+// arrayNames is the set of names Arrays uses
+func (s *Simulation) arrayNames() map[string]bool {
+	names := map[string]bool{}
+	for c := range s.field {
+		for _, name := range []string{"field", "n", "m"} {
+			names[arrayName(name, c, len(s.field))] = true
+		}
+	}
+	for k := range s.kernels {
+		for _, name := range []string{"inner", "annulus"} {
+			names[arrayName(name, k, len(s.kernels))] = true
+		}
+	}
+	return names
+}
+
+// SetArrays replaces parts of the state of the simulation with arrays named like
+// the ones Arrays returns. Fields are copied into their channel. Kernels are used
+// as they are, without normalising them, and an inner kernel needs its annulus.
+// n and m are accepted but ignored, since every step measures them afresh.
+func (s *Simulation) SetArrays(arrays map[string]*mat.Dense) error {
+	// Check everything before changing anything
+	fields := map[int]*mat.Dense{}
+	kernels := map[int]*Multipliers{}
+	for c := range s.field {
+		if field, ok := arrays[arrayName("field", c, len(s.field))]; ok {
+			if rows, cols := field.Dims(); rows != s.height || cols != s.width {
+				return fmt.Errorf("smoothlife: field is %dx%d, the simulation is %dx%d", cols, rows, s.width, s.height)
+			}
+			fields[c] = field
+		}
+	}
+	for k, mp := range s.kernels {
+		innerName, annulusName := arrayName("inner", k, len(s.kernels)), arrayName("annulus", k, len(s.kernels))
+		inner, hasInner := arrays[innerName]
+		annulus, hasAnnulus := arrays[annulusName]
+		if hasInner != hasAnnulus {
+			return fmt.Errorf("smoothlife: %s and %s must be set together", innerName, annulusName)
+		}
+		if !hasInner {
+			continue
+		}
+		wantRows, wantCols := mp.inner.Dims()
+		for _, kernel := range []*mat.Dense{inner, annulus} {
+			if rows, cols := kernel.Dims(); rows != wantRows || cols != wantCols {
+				return fmt.Errorf("smoothlife: kernel is %dx%d, the simulation needs %dx%d", cols, rows, wantCols, wantRows)
+			}
+		}
+		kernels[k] = &Multipliers{
+			inner:       mat.DenseCopyOf(inner),
+			outer:       mp.outer,
+			outerRadius: mp.outerRadius,
+			annulus:     mat.DenseCopyOf(annulus),
+			M:           rfft2dense(inner),
+			N:           rfft2dense(annulus),
+		}
+	}
+	known := s.arrayNames()
+	for name := range arrays {
+		if !known[name] {
+			return fmt.Errorf("smoothlife: unknown array %q", name)
+		}
+	}
+
+	for c, field := range fields {
+		s.field[c].Copy(field)
+	}
+	for k, mp := range kernels {
+		s.kernels[k] = mp
+		if k == 0 {
+			s.mp = mp
+		}
+	}
+	return nil
+}
+
+// SaveNPZ writes the arrays of the simulation to w as an .npz archive
+func (s *Simulation) SaveNPZ(w io.Writer) error {
+	return WriteNPZ(w, s.Arrays())
+}
+
+// LoadNPZ reads an .npz archive and sets the arrays it holds, see SetArrays
+func (s *Simulation) LoadNPZ(r io.ReaderAt, size int64) error {
+	arrays, err := ReadNPZ(r, size)
+	if err != nil {
+		return err
+	}
+	return s.SetArrays(arrays)
+}
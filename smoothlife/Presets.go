@@ -0,0 +1,130 @@
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Preset is a named SmoothLife parameter set, along with the kernel radii and time
+// step its patterns need
+type Preset struct {
+	Name        string
+	Description string
+	InnerRadius float64
+	OuterRadius float64
+	Rule        BasicRules
+	TimeStep    TimeStep
+}
+
+// Validate reports whether the preset can be applied
+func (p Preset) Validate() error {
+	if p.Name == "" {
+		return errors.New("smoothlife: a preset needs a name")
+	}
+	if err := (Kernel{InnerRadius: p.InnerRadius, OuterRadius: p.OuterRadius}).Validate(); err != nil {
+		return fmt.Errorf("smoothlife: preset %q: %w", p.Name, err)
+	}
+	if err := p.Rule.Validate(); err != nil {
+		return fmt.Errorf("smoothlife: preset %q: %w", p.Name, err)
+	}
+	if err := p.TimeStep.Validate(); err != nil {
+		return fmt.Errorf("smoothlife: preset %q: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Apply returns opts with the radii, rule and time step of the preset. The kernels
+// of a multi-channel simulation all take the radii, and every channel the rule.
+func (p Preset) Apply(opts Options) Options {
+	opts.InnerRadius, opts.OuterRadius = p.InnerRadius, p.OuterRadius
+	opts.Lenia = nil
+	if opts.Kernels != nil {
+		kernels := make([]Kernel, len(opts.Kernels))
+		for i, k := range opts.Kernels {
+			k.InnerRadius, k.OuterRadius, k.Lenia = p.InnerRadius, p.OuterRadius, nil
+			kernels[i] = k
+		}
+		opts.Kernels = kernels
+	}
+	opts.Rule = p.Rule
+	opts.ChannelRules = nil
+	opts.TimeStep = p.TimeStep
+	return opts
+}
+
+// paperRules are the rules of Rafler's paper, https://arxiv.org/abs/1111.1567
+var paperRules = BasicRules{B1: 0.278, B2: 0.365, D1: 0.267, D2: 0.445, N: 0.028, M: 0.147}
+
+var (
+	presetRegistryMu sync.RWMutex
+	presetRegistry   = map[string]Preset{}
+)
+
+// The built in presets come from the paper and from the rules of duckythescientist's
+// smoothlife.py, https://github.com/duckythescientist/SmoothLife
+func init() {
+	for _, p := range []Preset{
+		{
+			Name:        "paper",
+			Description: "the discrete rules of the paper, with the outer radius three times the inner",
+			InnerRadius: 7, OuterRadius: 21,
+			Rule:     paperRules,
+			TimeStep: TimeStep{Mode: Discrete, Dt: 0.1},
+		},
+		{
+			Name:        "duckythescientist",
+			Description: "the discrete rules of the paper on the large kernel of duckythescientist's Python version, the original settings of this port",
+			InnerRadius: 20, OuterRadius: 60,
+			Rule:     paperRules,
+			TimeStep: TimeStep{Mode: Discrete, Dt: 0.1},
+		},
+		{
+			Name:        "duckythescientist-smooth",
+			Description: "the SmoothTimestepRules of duckythescientist's Python version, growing and shrinking the field by dt",
+			InnerRadius: 7, OuterRadius: 21,
+			Rule:     BasicRules{B1: 0.254, B2: 0.312, D1: 0.340, D2: 0.518, N: 0.028, M: 0.147},
+			TimeStep: TimeStep{Mode: SmoothSigned, Dt: 0.1},
+		},
+	} {
+		RegisterPreset(p)
+	}
+}
+
+// RegisterPreset adds p to the catalogue under its name.
+// Registering an invalid preset or a name twice panics.
+func RegisterPreset(p Preset) {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	presetRegistryMu.Lock()
+	defer presetRegistryMu.Unlock()
+	if _, ok := presetRegistry[p.Name]; ok {
+		panic(fmt.Sprintf("smoothlife: preset %q registered twice", p.Name))
+	}
+	presetRegistry[p.Name] = p
+}
+
+// LookupPreset returns the preset registered under name
+func LookupPreset(name string) (Preset, error) {
+	presetRegistryMu.RLock()
+	defer presetRegistryMu.RUnlock()
+	p, ok := presetRegistry[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("smoothlife: unknown preset %q", name)
+	}
+	return p, nil
+}
+
+// PresetNames lists the registered presets in alphabetical order
+func PresetNames() []string {
+	presetRegistryMu.RLock()
+	defer presetRegistryMu.RUnlock()
+	names := make([]string, 0, len(presetRegistry))
+	for name := range presetRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,66 @@
+package smoothlife
+
+import (
+	"math/rand"
+	"time"
+)
+
+// countingSource counts the values drawn from a seeded source, so that its state
+// can be recorded as the seed and the number of draws
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (cs *countingSource) Int63() int64 {
+	cs.draws++
+	return cs.src.Int63()
+}
+
+func (cs *countingSource) Uint64() uint64 {
+	cs.draws++
+	return cs.src.Uint64()
+}
+
+func (cs *countingSource) Seed(seed int64) {
+	cs.src.Seed(seed)
+	cs.draws = 0
+}
+
+// skip advances the source by n draws
+func (cs *countingSource) skip(n uint64) {
+	for ; n > 0; n-- {
+		cs.Int63()
+	}
+}
+
+// clockSeed picks a seed for callers that did not give one
+func clockSeed() int64 {
+	seed := time.Now().UnixNano()
+	if seed == 0 {
+		seed = 1
+	}
+	return seed
+}
+
+// Seed returns the seed of the random source of the simulation
+func (sl *SmoothLife) Seed() int64 {
+	return sl.seed
+}
+
+// Rand returns the random source every random operation of the simulation draws
+// from. Drawing from it directly is fine, the draws are recorded in snapshots.
+func (sl *SmoothLife) Rand() *rand.Rand {
+	return sl.rng
+}
+
+// Reseed restarts the random source of the simulation from seed
+func (sl *SmoothLife) Reseed(seed int64) {
+	sl.seed = seed
+	sl.source = newCountingSource(seed)
+	sl.rng = rand.New(sl.source)
+}
@@ -0,0 +1,200 @@
+package smoothlife
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// RenderMode selects what a Renderer colours the cells by
+type RenderMode int
+
+const (
+	// RenderField colours the value of each cell with the colour map. With several
+	// channels the first three are drawn as red, green and blue instead.
+	RenderField RenderMode = iota
+	// RenderRate colours the change of each cell per step with the colour map, no
+	// change falling in its middle
+	RenderRate
+	// RenderDensities draws the inner density m in red, the field in green and the
+	// outer density n in blue
+	RenderDensities
+	// RenderMotion takes the hue from the direction the pattern moves in, the
+	// saturation from its speed and the brightness from the field
+	RenderMotion
+)
+
+var renderModeNames = map[RenderMode]string{
+	RenderField:     "field",
+	RenderRate:      "rate",
+	RenderDensities: "densities",
+	RenderMotion:    "motion",
+}
+
+func (mode RenderMode) String() string {
+	if name, ok := renderModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RenderMode(%d)", int(mode))
+}
+
+// ParseRenderMode looks up a render mode by the name String returns
+func ParseRenderMode(name string) (RenderMode, error) {
+	for mode, n := range renderModeNames {
+		if n == name {
+			return mode, nil
+		}
+	}
+	return RenderField, fmt.Errorf("smoothlife: unknown render mode %q", name)
+}
+
+// defaultGain suits the rates of the smooth time step modes
+const defaultGain = 10
+
+// Renderer draws the first channel of a simulation into opaque RGBA images. It
+// remembers the previous frame to measure rates of change and motion, so each
+// simulation needs its own Renderer.
+type Renderer struct {
+	Mode RenderMode
+	// Colormap colours RenderField and RenderRate, nil means Grayscale
+	Colormap *Colormap
+	// Gain scales rates of change and speeds before they are coloured, 0 means 10
+	Gain float64
+
+	previous *mat.Dense
+	change   *mat.Dense
+	step     uint64
+}
+
+// Palette returns the colours a frame can hold if they all come from the colour
+// map, and nil otherwise
+func (r *Renderer) Palette(sl *SmoothLife) color.Palette {
+	if r.Mode == RenderRate || r.Mode == RenderField && sl.Channels() == 1 {
+		return r.colormap().Palette()
+	}
+	return nil
+}
+
+func (r *Renderer) colormap() *Colormap {
+	if r.Colormap == nil {
+		return Grayscale
+	}
+	return r.Colormap
+}
+
+func (r *Renderer) gain() float64 {
+	if r.Gain == 0 {
+		return defaultGain
+	}
+	return r.Gain
+}
+
+// Render draws the simulation into img, which must be the size of the field
+func (r *Renderer) Render(img *image.RGBA, sl *SmoothLife) {
+	r.track(sl)
+	cm := r.colormap()
+	gain := r.gain()
+	field := sl.field[0].RawMatrix().Data
+	change := r.change.RawMatrix().Data
+	n, m := sl.ws.n[0].RawMatrix().Data, sl.ws.m[0].RawMatrix().Data
+	for y := 0; y < sl.height; y++ {
+		for x := 0; x < sl.width; x++ {
+			cell := y*sl.width + x
+			var c color.RGBA
+			switch r.Mode {
+			case RenderRate:
+				c = cm.At(0.5 + gain*change[cell]/2)
+			case RenderDensities:
+				c = color.RGBA{R: unit(m[cell]), G: unit(field[cell]), B: unit(n[cell]), A: 0xff}
+			case RenderMotion:
+				c = r.motion(sl, x, y, gain)
+			default:
+				if sl.Channels() == 1 {
+					c = cm.At(field[cell])
+					break
+				}
+				c = color.RGBA{R: unit(field[cell]), G: unit(sl.field[1].RawMatrix().Data[cell]), A: 0xff}
+				if sl.Channels() > 2 {
+					c.B = unit(sl.field[2].RawMatrix().Data[cell])
+				}
+			}
+			index := y*img.Stride + x*4
+			img.Pix[index], img.Pix[index+1], img.Pix[index+2], img.Pix[index+3] = c.R, c.G, c.B, 0xff
+		}
+	}
+}
+
+// track updates the change per step of the first channel when the simulation has
+// stepped since the last frame
+func (r *Renderer) track(sl *SmoothLife) {
+	field := sl.field[0]
+	if r.previous == nil || !sameDims(r.previous, field) {
+		r.previous = mat.DenseCopyOf(field)
+		r.change = mat.NewDense(sl.height, sl.width, nil)
+		r.step = sl.steps
+		return
+	}
+	if sl.steps == r.step {
+		return
+	}
+	r.change.Sub(field, r.previous)
+	if sl.steps > r.step {
+		r.change.Scale(1/float64(sl.steps-r.step), r.change)
+	}
+	r.previous.Copy(field)
+	r.step = sl.steps
+}
+
+// motion estimates the normal flow -f_t grad f / |grad f|^2 of the field at (x, y)
+func (r *Renderer) motion(sl *SmoothLife, x int, y int, gain float64) color.RGBA {
+	field := sl.field[0].RawMatrix().Data
+	at := func(x, y int) float64 {
+		return field[wrap(y, sl.height)*sl.width+wrap(x, sl.width)]
+	}
+	gx := (at(x+1, y) - at(x-1, y)) / 2
+	gy := (at(x, y+1) - at(x, y-1)) / 2
+	ft := r.change.RawMatrix().Data[y*sl.width+x]
+	norm := gx*gx + gy*gy + 1e-6
+	vx, vy := -ft*gx/norm, -ft*gy/norm
+	hue := math.Atan2(vy, vx)/(2*math.Pi) + 0.5
+	saturation := Clamp(gain*math.Hypot(vx, vy), 0, 1)
+	return hsv(hue, saturation, Clamp(at(x, y), 0, 1))
+}
+
+// hsv converts a hue, saturation and value, all in [0,1], to an opaque colour
+func hsv(h float64, s float64, v float64) color.RGBA {
+	h = math.Mod(h, 1) * 6
+	sector := int(h)
+	f := h - float64(sector)
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+	var r, g, b float64
+	switch sector {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.RGBA{R: unit(r), G: unit(g), B: unit(b), A: 0xff}
+}
+
+// unit converts a value in [0,1] to a colour component, clamping it first
+func unit(v float64) uint8 {
+	return uint8(math.Round(Clamp(v, 0, 1) * 0xff))
+}
+
+func sameDims(a *mat.Dense, b *mat.Dense) bool {
+	ar, ac := a.Dims()
+	br, bc := b.Dims()
+	return ar == br && ac == bc
+}
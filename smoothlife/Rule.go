@@ -0,0 +1,73 @@
+package smoothlife
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Rule is a transition function. Apply writes the next value of every cell into dst,
+// given the annulus density n, the inner density m and the current field. Rules that
+// are pure functions of n and m may ignore field, incremental rules build on it.
+// dst has the same dimensions as the inputs and never aliases them.
+type Rule interface {
+	Apply(dst, n, m, field *mat.Dense)
+}
+
+// RuleFunc adapts a function of a single cell to the Rule interface, f is the
+// current value of the cell
+type RuleFunc func(n, m, f float64) float64
+
+func (rf RuleFunc) Apply(dst, n, m, field *mat.Dense) {
+	rows, cols := dst.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			dst.Set(i, j, rf(n.At(i, j), m.At(i, j), field.At(i, j)))
+		}
+	}
+}
+
+var (
+	ruleRegistryMu sync.RWMutex
+	ruleRegistry   = map[string]func() Rule{
+		"basic": func() Rule { return &BasicRules{} },
+		"lenia": func() Rule { return &LeniaRules{Mu: 0.15, Sigma: 0.015, Growth: GrowthGaussian, T: 10} },
+	}
+)
+
+// RegisterRule makes a rule available under name. newRule must return a fresh rule,
+// a pointer if its parameters are to be filled in by the caller.
+// Registering a name twice panics.
+func RegisterRule(name string, newRule func() Rule) {
+	ruleRegistryMu.Lock()
+	defer ruleRegistryMu.Unlock()
+	if _, ok := ruleRegistry[name]; ok {
+		panic(fmt.Sprintf("smoothlife: rule %q registered twice", name))
+	}
+	ruleRegistry[name] = newRule
+}
+
+// NewRule returns a fresh rule registered under name
+func NewRule(name string) (Rule, error) {
+	ruleRegistryMu.RLock()
+	defer ruleRegistryMu.RUnlock()
+	newRule, ok := ruleRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("smoothlife: unknown rule %q", name)
+	}
+	return newRule(), nil
+}
+
+// RuleNames lists the registered rules in alphabetical order
+func RuleNames() []string {
+	ruleRegistryMu.RLock()
+	defer ruleRegistryMu.RUnlock()
+	names := make([]string, 0, len(ruleRegistry))
+	for name := range ruleRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package smoothlife
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+type BasicRules struct {
+	B1 float64
+	B2 float64
+	D1 float64
+	D2 float64
+	N  float64
+	M  float64
+
+	// Sigmoid shapes for the aliveness test on m, the interval test on n and the
+	// mixing of the birth and death intervals. The zero values keep the original shapes.
+	AliveSigmoid    Sigmoid
+	IntervalSigmoid Sigmoid
+	MixSigmoid      Sigmoid
+}
+
+func (BasicRules BasicRules) Clear() {
+}
+
+// Validate reports whether the sigmoid shapes are known
+func (br BasicRules) Validate() error {
+	for _, s := range []Sigmoid{br.AliveSigmoid, br.IntervalSigmoid, br.MixSigmoid} {
+		if _, ok := sigmoidNames[s]; !ok {
+			return fmt.Errorf("smoothlife: unknown sigmoid %v", s)
+		}
+	}
+	return nil
+}
+
+// State transition function
+func (br BasicRules) S(n *mat.Dense, m *mat.Dense) *mat.Dense {
+	rows, cols := n.Dims()
+	output := mat.NewDense(rows, cols, nil)
+	br.Apply(output, n, m, nil)
+	return output
+}
+
+// Apply implements Rule, the basic rules are a pure function of n and m
+func (br BasicRules) Apply(dst, n, m, field *mat.Dense) {
+	rows, cols := dst.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			dst.Set(i, j, br.s(n.At(i, j), m.At(i, j)))
+		}
+	}
+}
+
+// s is the state transition function for a single cell
+func (br BasicRules) s(n float64, m float64) float64 {
+	return br.stages(n, m).s
+}
+
+// ruleStages holds the intermediate values of the basic rules for a single cell
+type ruleStages struct {
+	aliveness  float64
+	threshold1 float64
+	threshold2 float64
+	s          float64
+}
+
+func (br BasicRules) stages(n float64, m float64) ruleStages {
+	// Convert the local cell average `m` to a metric of how alive the local cell is.
+	// We transition around 0.5 (0 is fully dead and 1 is fully alive).
+	// The transition width is set by `br.M`
+	aliveness := br.AliveSigmoid.or(SigmoidLogistic).Threshold(m, 0.5, br.M)
+
+	// A fully dead cell will become alive if the neighbor density is between B1 and B2.
+	// A fully alive cell will stay alive if the neighhbor density is between D1 and D2.
+	// Interpolate between the two sets of thresholds depending on how alive/dead the cell is.
+	// {B1: 0.278, B2: 0.365, D1: 0.267, D2: 0.445, N: 0.028, M: 0.147}
+	weight := aliveness
+	if br.MixSigmoid != SigmoidDefault {
+		weight = br.MixSigmoid.Threshold(aliveness, 0.5, 1)
+	}
+	threshold1 := (1.0-weight)*br.B1 + weight*br.D1
+	threshold2 := (1.0-weight)*br.B2 + weight*br.D2
+	newAliveness := br.IntervalSigmoid.or(SigmoidLogistic).Interval(n, threshold1, threshold2, br.N)
+
+	return ruleStages{
+		aliveness:  aliveness,
+		threshold1: threshold1,
+		threshold2: threshold2,
+		s:          Clamp(newAliveness, 0, 1),
+	}
+}
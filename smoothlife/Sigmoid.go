@@ -0,0 +1,87 @@
+package smoothlife
+
+import (
+	"fmt"
+	"math"
+)
+
+// Sigmoid selects the shape of a smooth step, mirroring the sigtype/mixtype
+// options of the snm2D shader
+type Sigmoid int
+
+const (
+	// SigmoidDefault keeps the shape BasicRules has always used for a step:
+	// logistic for the aliveness and interval tests, linear for the mixing
+	SigmoidDefault Sigmoid = iota
+	// SigmoidHard is a step function, 1 where x > x0
+	SigmoidHard
+	// SigmoidLinear ramps linearly across a transition region of the given width
+	SigmoidLinear
+	// SigmoidLogistic is the logistic curve used by LogisticThreshold
+	SigmoidLogistic
+	// SigmoidCubic is the Hermite smoothstep across the transition region
+	SigmoidCubic
+	// SigmoidSine is a half cosine wave across the transition region
+	SigmoidSine
+)
+
+var sigmoidNames = map[Sigmoid]string{
+	SigmoidDefault:  "default",
+	SigmoidHard:     "hard",
+	SigmoidLinear:   "linear",
+	SigmoidLogistic: "logistic",
+	SigmoidCubic:    "cubic",
+	SigmoidSine:     "sine",
+}
+
+func (s Sigmoid) String() string {
+	if name, ok := sigmoidNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Sigmoid(%d)", int(s))
+}
+
+// ParseSigmoid looks up a sigmoid by the name String returns
+func ParseSigmoid(name string) (Sigmoid, error) {
+	for s, n := range sigmoidNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return SigmoidDefault, fmt.Errorf("smoothlife: unknown sigmoid %q", name)
+}
+
+// or returns fallback in place of SigmoidDefault
+func (s Sigmoid) or(fallback Sigmoid) Sigmoid {
+	if s == SigmoidDefault {
+		return fallback
+	}
+	return s
+}
+
+// Threshold smoothly steps from 0 to 1 as x passes x0, over a transition region of
+// roughly the given width
+func (s Sigmoid) Threshold(x float64, x0 float64, width float64) float64 {
+	switch s {
+	case SigmoidHard:
+		if HardThreshold(x, x0) {
+			return 1
+		}
+		return 0
+	case SigmoidLinear:
+		return LinearisedThreshold(x, x0, width)
+	case SigmoidCubic:
+		t := LinearisedThreshold(x, x0, width)
+		return t * t * (3 - 2*t)
+	case SigmoidSine:
+		t := LinearisedThreshold(x, x0, width)
+		return 0.5 - 0.5*math.Cos(math.Pi*t)
+	default:
+		return LogisticThreshold(x, x0, width)
+	}
+}
+
+// Interval is ~1 for a < x < b and ~0 elsewhere, with transitions of the given width
+func (s Sigmoid) Interval(x float64, a float64, b float64, width float64) float64 {
+	return s.Threshold(x, a, width) * (1.0 - s.Threshold(x, b, width))
+}
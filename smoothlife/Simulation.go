@@ -0,0 +1,346 @@
+// Package smoothlife implements SmoothLife, a continuous domain generalisation of
+// Conway's Game of Life, on top of FFT convolutions.
+package smoothlife
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Options describes everything needed to build a Simulation
+type Options struct {
+	Width       int
+	Height      int
+	InnerRadius float64
+	OuterRadius float64
+	// LogRes sets the antialiasing sharpness of the kernel edges, 0 picks one from the grid size
+	LogRes float64
+	// Lenia replaces the SmoothLife disc and annulus with a Lenia ring kernel, the
+	// radii and LogRes are then ignored
+	Lenia *LeniaKernel
+	// Rule is the transition function, usually BasicRules
+	Rule     Rule
+	TimeStep TimeStep
+
+	// Channels is the number of fields in the simulation, 0 is treated as 1
+	Channels int
+	// Kernels lists the convolutions of a multi-channel simulation. By default there
+	// is a single kernel over channel 0 built from the radii, LogRes and Lenia above.
+	Kernels []Kernel
+	// Weights[c][k] scales the densities measured by kernel k before they are fed to
+	// the rule of channel c. By default each kernel feeds the channel it reads, and
+	// channels read by several kernels take an equal share of each.
+	Weights [][]float64
+	// ChannelRules gives each channel its own transition function, by default every
+	// channel uses Rule
+	ChannelRules []Rule
+
+	// Boundary selects what the kernels see beyond the edges of the field,
+	// BoundaryValue is the value of the cells outside a BoundaryFixed field
+	Boundary      Boundary
+	BoundaryValue float64
+
+	// Seed seeds the random source of the simulation, 0 picks one from the clock.
+	// The same seed and options always give the same run.
+	Seed int64
+}
+
+// Kernel is a single convolution of a multi-channel simulation
+type Kernel struct {
+	// Source is the channel the kernel convolves
+	Source      int
+	InnerRadius float64
+	OuterRadius float64
+	LogRes      float64
+	// Lenia replaces the disc and annulus with a Lenia ring kernel
+	Lenia *LeniaKernel
+}
+
+// Validate reports whether the kernel can be built
+func (k Kernel) Validate() error {
+	if k.Lenia != nil {
+		return k.Lenia.Validate()
+	} else if k.InnerRadius <= 0 {
+		return fmt.Errorf("smoothlife: inner radius must be positive, got %v", k.InnerRadius)
+	} else if k.OuterRadius <= k.InnerRadius {
+		return errors.New("smoothlife: outer radius must be larger than the inner radius")
+	} else if k.LogRes < 0 {
+		return fmt.Errorf("smoothlife: logres must not be negative, got %v", k.LogRes)
+	}
+	return nil
+}
+
+// Multipliers precomputes the FFTs of the kernel for a width by height grid
+func (k Kernel) Multipliers(width int, height int) *Multipliers {
+	if k.Lenia != nil {
+		return ConstructLeniaMultipliers(*k.Lenia, width, height)
+	}
+	return ConstructMultipliers(k.InnerRadius, k.OuterRadius, width, height, k.LogRes)
+}
+
+// channels returns the number of channels, treating 0 as 1
+func (o Options) channels() int {
+	if o.Channels < 1 {
+		return 1
+	}
+	return o.Channels
+}
+
+// kernels returns Kernels, or the single kernel described by the top level fields
+func (o Options) kernels() []Kernel {
+	if len(o.Kernels) > 0 {
+		return append([]Kernel(nil), o.Kernels...)
+	}
+	return []Kernel{{
+		Source:      0,
+		InnerRadius: o.InnerRadius,
+		OuterRadius: o.OuterRadius,
+		LogRes:      o.LogRes,
+		Lenia:       o.Lenia,
+	}}
+}
+
+// weights returns Weights, or the default kernel to channel matrix
+func (o Options) weights() [][]float64 {
+	if o.Weights != nil {
+		return o.Weights
+	}
+	kernels := o.kernels()
+	weights := make([][]float64, o.channels())
+	for c := range weights {
+		weights[c] = make([]float64, len(kernels))
+		var count float64
+		for _, k := range kernels {
+			if k.Source == c {
+				count++
+			}
+		}
+		for i, k := range kernels {
+			if k.Source == c {
+				weights[c][i] = 1 / count
+			}
+		}
+	}
+	return weights
+}
+
+// rules returns the rule of every channel
+func (o Options) rules() []Rule {
+	if len(o.ChannelRules) > 0 {
+		return append([]Rule(nil), o.ChannelRules...)
+	}
+	rules := make([]Rule, o.channels())
+	for c := range rules {
+		rules[c] = o.Rule
+	}
+	return rules
+}
+
+// DefaultOptions returns the parameters the original viewer shipped with
+func DefaultOptions() Options {
+	return Options{
+		Width:       1 << 9,
+		Height:      1 << 9,
+		InnerRadius: 20.0,
+		OuterRadius: 60.0,
+		LogRes:      0.5,
+		// Birth range, survival range, sigmoid widths
+		Rule:     BasicRules{B1: 0.278, B2: 0.365, D1: 0.267, D2: 0.445, N: 0.028, M: 0.147},
+		TimeStep: TimeStep{Mode: Discrete, Integrator: Euler, Dt: 0.1},
+	}
+}
+
+// Validate reports the first problem found in the options, if any
+func (o Options) Validate() error {
+	if o.Width <= 0 || o.Height <= 0 {
+		return fmt.Errorf("smoothlife: grid size must be positive, got %dx%d", o.Width, o.Height)
+	}
+	channels := o.channels()
+	kernels := o.kernels()
+	for i, k := range kernels {
+		if k.Source < 0 || k.Source >= channels {
+			return fmt.Errorf("smoothlife: kernel %d reads channel %d of %d", i, k.Source, channels)
+		}
+		if err := k.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Weights != nil {
+		if len(o.Weights) != channels {
+			return fmt.Errorf("smoothlife: weights need a row per channel, got %d rows for %d channels", len(o.Weights), channels)
+		}
+		for c, row := range o.Weights {
+			if len(row) != len(kernels) {
+				return fmt.Errorf("smoothlife: weights row %d needs a column per kernel, got %d for %d kernels", c, len(row), len(kernels))
+			}
+		}
+	}
+	if len(o.ChannelRules) > 0 && len(o.ChannelRules) != channels {
+		return fmt.Errorf("smoothlife: got %d channel rules for %d channels", len(o.ChannelRules), channels)
+	}
+	for _, rule := range o.rules() {
+		if rule == nil {
+			return errors.New("smoothlife: a rule is required")
+		}
+		if v, ok := rule.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := boundaryNames[o.Boundary]; !ok {
+		return fmt.Errorf("smoothlife: unknown boundary %v", o.Boundary)
+	}
+	if o.Boundary == BoundaryFixed && (o.BoundaryValue < 0 || o.BoundaryValue > 1) {
+		return fmt.Errorf("smoothlife: fixed boundary value must be in [0,1], got %v", o.BoundaryValue)
+	}
+	return o.TimeStep.Validate()
+}
+
+// Simulation bundles a SmoothLife field with the kernels it was built from.
+// Simulations share no state, so several can run side by side.
+type Simulation struct {
+	*SmoothLife
+	options Options
+}
+
+// ConstructSimulation builds the kernels and an empty field described by opts
+func ConstructSimulation(opts Options) (*Simulation, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	kernels := opts.kernels()
+	// Pick the antialiasing up front, so that padding cannot change it
+	var extent float64
+	for i, k := range kernels {
+		if k.Lenia == nil && k.LogRes == 0 {
+			kernels[i].LogRes = math.Log2(math.Min(float64(opts.Width), float64(opts.Height)))
+		}
+		extent = math.Max(extent, kernels[i].extent())
+	}
+	var pad int
+	if opts.Boundary != BoundaryPeriodic {
+		pad = int(math.Ceil(extent))
+	}
+
+	multipliers := make([]*Multipliers, len(kernels))
+	sources := make([]int, len(kernels))
+	for i, k := range kernels {
+		multipliers[i] = k.Multipliers(opts.Width+2*pad, opts.Height+2*pad)
+		sources[i] = k.Source
+	}
+	sl := ConstructMultiChannelSmoothLife(opts.rules(), multipliers, sources, opts.weights(), opts.Width, opts.Height)
+	if pad > 0 {
+		sl.setBoundary(opts.Boundary, opts.BoundaryValue, pad)
+	}
+	sl.SetTimeStep(opts.TimeStep)
+	if opts.Seed == 0 {
+		opts.Seed = clockSeed()
+	}
+	sl.Reseed(opts.Seed)
+	return &Simulation{
+		SmoothLife: sl,
+		options:    opts,
+	}, nil
+}
+
+// Options returns the options the simulation was constructed with
+func (s *Simulation) Options() Options {
+	return s.options
+}
+
+// Multipliers returns the precomputed kernels of the first convolution
+func (s *Simulation) Multipliers() *Multipliers {
+	return s.mp
+}
+
+// Kernel returns the precomputed kernels of convolution k
+func (s *Simulation) Kernel(k int) *Multipliers {
+	return s.kernels[k]
+}
+
+// SetTimeStep changes how subsequent calls to Step advance the field
+func (s *Simulation) SetTimeStep(ts TimeStep) error {
+	if err := ts.Validate(); err != nil {
+		return err
+	}
+	s.SmoothLife.SetTimeStep(ts)
+	s.options.TimeStep = ts
+	return nil
+}
+
+// SetRule makes every channel use rule from the next call to Step
+func (s *Simulation) SetRule(rule Rule) error {
+	if rule == nil {
+		return errors.New("smoothlife: a rule is required")
+	}
+	s.SmoothLife.SetRule(rule)
+	s.options.Rule = rule
+	s.options.ChannelRules = nil
+	return nil
+}
+
+// SetChannelRule makes channel c use rule from the next call to Step
+func (s *Simulation) SetChannelRule(c int, rule Rule) error {
+	if rule == nil {
+		return errors.New("smoothlife: a rule is required")
+	}
+	if c < 0 || c >= s.Channels() {
+		return fmt.Errorf("smoothlife: no channel %d of %d", c, s.Channels())
+	}
+	s.SmoothLife.SetChannelRule(c, rule)
+	rules := make([]Rule, s.Channels())
+	copy(rules, s.rules)
+	s.options.ChannelRules = rules
+	return nil
+}
+
+// Reseed restarts the random source of the simulation from seed and records it in
+// the options
+func (s *Simulation) Reseed(seed int64) {
+	s.SmoothLife.Reseed(seed)
+	s.options.Seed = seed
+}
+
+// Reconfigure rebuilds the simulation for opts, which may change anything but the
+// size of the grid and the number of channels. The field, the step count and the
+// random source carry over, the seed of opts is ignored.
+func (s *Simulation) Reconfigure(opts Options) error {
+	if err := s.compatible(opts); err != nil {
+		return err
+	}
+	next, err := ConstructSimulation(opts)
+	if err != nil {
+		return err
+	}
+	return s.Adopt(next)
+}
+
+// Adopt takes over the kernels, rules and time step of next, a simulation of the
+// same size and channels, carrying over the field, the step count and the random
+// source. Building next is the slow part, so it can happen on another goroutine
+// while s keeps stepping, and next must not be used afterwards. The engine of s
+// changes in place, so method values taken from s before stay bound to it.
+func (s *Simulation) Adopt(next *Simulation) error {
+	if err := s.compatible(next.options); err != nil {
+		return err
+	}
+	for c, field := range s.field {
+		next.field[c].Copy(field)
+	}
+	next.steps = s.steps
+	next.seed, next.source, next.rng = s.seed, s.source, s.rng
+	*s.SmoothLife = *next.SmoothLife
+	s.options = next.options
+	s.options.Seed = s.seed
+	return nil
+}
+
+func (s *Simulation) compatible(opts Options) error {
+	if opts.Width != s.width || opts.Height != s.height || opts.channels() != s.Channels() {
+		return fmt.Errorf("smoothlife: cannot reconfigure a %dx%d simulation of %d channels to %dx%d with %d",
+			s.width, s.height, s.Channels(), opts.Width, opts.Height, opts.channels())
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package smoothlife
+
+import (
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ConstructSmoothLife builds a single channel simulation measuring m and n with mp
+func ConstructSmoothLife(mp *Multipliers, rule Rule, width int, height int) *SmoothLife {
+	return ConstructMultiChannelSmoothLife(
+		[]Rule{rule},
+		[]*Multipliers{mp},
+		[]int{0},
+		[][]float64{{1}},
+		width,
+		height,
+	)
+}
+
+// ConstructMultiChannelSmoothLife builds a simulation with one channel per rule.
+// Kernel k convolves channel sources[k], and the m and n seen by the rule of channel c
+// are the sums of the kernel densities weighted by weights[c][k].
+func ConstructMultiChannelSmoothLife(
+	rules []Rule,
+	kernels []*Multipliers,
+	sources []int,
+	weights [][]float64,
+	width int,
+	height int,
+) *SmoothLife {
+	sl := &SmoothLife{
+		width:   width,
+		height:  height,
+		mp:      kernels[0],
+		kernels: kernels,
+		sources: sources,
+		weights: weights,
+		rules:   rules,
+		ws:      newWorkspace(len(rules), len(kernels), width, height, 0),
+	}
+	sl.Reseed(1)
+	sl.Clear()
+	return sl
+}
+
+type SmoothLife struct {
+	width    int
+	height   int
+	mp       *Multipliers
+	kernels  []*Multipliers
+	sources  []int
+	weights  [][]float64
+	rules    []Rule
+	timeStep TimeStep
+	field    []*mat.Dense
+	ws       *workspace
+	steps    uint64
+
+	// Every random operation draws from rng, see Reseed
+	seed   int64
+	source *countingSource
+	rng    *rand.Rand
+
+	boundary      Boundary
+	boundaryValue float64
+}
+
+// Field returns the current state of the first channel
+func (sl *SmoothLife) Field() *mat.Dense {
+	return sl.field[0]
+}
+
+// Channel returns the current state of channel c
+func (sl *SmoothLife) Channel(c int) *mat.Dense {
+	return sl.field[c]
+}
+
+// StepCount returns the number of steps taken since the simulation was built
+func (sl *SmoothLife) StepCount() uint64 {
+	return sl.steps
+}
+
+// Densities returns the outer density n and inner density m the rule of channel c
+// saw in the last step. They are overwritten by the next step.
+func (sl *SmoothLife) Densities(c int) (n *mat.Dense, m *mat.Dense) {
+	return sl.ws.n[c], sl.ws.m[c]
+}
+
+// Channels returns the number of channels in the simulation
+func (sl *SmoothLife) Channels() int {
+	return len(sl.field)
+}
+
+func (sl *SmoothLife) Clear() {
+	if sl.field == nil {
+		sl.field = newDenses(len(sl.rules), sl.width, sl.height)
+	}
+	for _, field := range sl.field {
+		field.Zero()
+	}
+}
+
+// SetRule makes every channel use rule from the next call to Step
+func (sl *SmoothLife) SetRule(rule Rule) {
+	for c := range sl.rules {
+		sl.rules[c] = rule
+	}
+}
+
+// SetChannelRule swaps the transition function of channel c
+func (sl *SmoothLife) SetChannelRule(c int, rule Rule) {
+	sl.rules[c] = rule
+}
+
+// SetTimeStep changes how subsequent calls to Step advance the field
+func (sl *SmoothLife) SetTimeStep(ts TimeStep) {
+	sl.timeStep = ts
+}
+
+// convolve measures the densities n and m seen by the rule of each channel into
+// the workspace
+func (sl *SmoothLife) convolve(field []*mat.Dense) {
+	ws := sl.ws
+	for c := range ws.transformed {
+		ws.transformed[c] = false
+	}
+	for k, mp := range sl.kernels {
+		source := sl.sources[k]
+		if !ws.transformed[source] {
+			sl.transform(ws.spectra[source], field[source])
+			ws.transformed[source] = true
+		}
+		sl.convolveSpectrum(ws.kernelM[k], ws.spectra[source], mp.M)
+		if mp.N == mp.M {
+			// A Lenia kernel measures one density, so it needs one inverse transform
+			ws.kernelN[k].Copy(ws.kernelM[k])
+			continue
+		}
+		sl.convolveSpectrum(ws.kernelN[k], ws.spectra[source], mp.N)
+	}
+
+	for c := range field {
+		ws.n[c].Zero()
+		ws.m[c].Zero()
+		for k, weight := range sl.weights[c] {
+			if weight == 0 {
+				continue
+			}
+			addScaled(ws.n[c], weight, ws.kernelN[k])
+			addScaled(ws.m[c], weight, ws.kernelM[k])
+		}
+	}
+}
+
+// addScaled adds alpha*x to dst element-wise
+func addScaled(dst *mat.Dense, alpha float64, x *mat.Dense) {
+	dData, xData := dst.RawMatrix().Data, x.RawMatrix().Data
+	for i := range dData {
+		dData[i] += alpha * xData[i]
+	}
+}
+
+// apply runs the rule of every channel on the densities in the workspace
+func (sl *SmoothLife) apply(dst []*mat.Dense, field []*mat.Dense) {
+	for c := range field {
+		sl.rules[c].Apply(dst[c], sl.ws.n[c], sl.ws.m[c], field[c])
+	}
+}
+
+// Step advances every channel and returns the first one. Step reuses its buffers,
+// so the returned matrix is overwritten by later steps.
+func (sl *SmoothLife) Step() *mat.Dense {
+	if sl.timeStep.Mode == Discrete {
+		sl.convolve(sl.field)
+		sl.apply(sl.ws.next, sl.field)
+	} else {
+		sl.integrate(sl.ws.next, sl.field)
+	}
+	sl.field, sl.ws.next = sl.ws.next, sl.field
+	sl.steps++
+	return sl.field[0]
+}
+
+// AddSpeckles stamps squares of live cells onto every channel at positions drawn
+// from the random source of the simulation
+func (sl *SmoothLife) AddSpeckles() {
+	sl.AddSpecklesFrom(sl.rng)
+}
+
+// AddSpecklesFrom is AddSpeckles drawing the positions from rng
+func (sl *SmoothLife) AddSpecklesFrom(rng *rand.Rand) {
+	speckles := Speckles{Count: 25, Size: sl.mp.outerRadius, Value: 1}
+	for _, field := range sl.field {
+		speckles.Generate(field, rng)
+	}
+}
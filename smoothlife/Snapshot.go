@@ -0,0 +1,399 @@
+package smoothlife
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Snapshots are little endian binary files laid out as
+//
+//	magic "SLGO", version uint16
+//	options: grid size, kernels, rules, time step and boundary
+//	state: step count, random draws, then every channel as height*width float64 values
+//	CRC-32 (IEEE) of everything before it
+const (
+	snapshotMagic   = "SLGO"
+	snapshotVersion = 1
+
+	// Limits on the sizes read from a snapshot. Values are read in chunks as they
+	// arrive, so a corrupt or truncated file fails before its sizes can ask for
+	// more memory than the bytes it holds.
+	maxSnapshotCells = 1 << 24
+	maxSnapshotCount = 1 << 16
+)
+
+// Rule tags used in snapshots
+const (
+	snapshotRuleBasic uint8 = iota + 1
+	snapshotRuleLenia
+)
+
+var errSnapshotChecksum = errors.New("smoothlife: snapshot checksum mismatch")
+
+// Save writes the options and the complete state of the simulation to w.
+// Only BasicRules and LeniaRules can be saved.
+func (s *Simulation) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	sw := &snapshotWriter{w: bw, crc: crc32.NewIEEE()}
+	sw.bytes([]byte(snapshotMagic))
+	sw.u16(snapshotVersion)
+	sw.options(s.options)
+	sw.u64(s.steps)
+	sw.u64(s.source.draws)
+	sw.u32(uint32(len(s.field)))
+	for _, field := range s.field {
+		sw.f64s(field.RawMatrix().Data)
+	}
+	if sw.err != nil {
+		return sw.err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, sw.crc.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadSimulation builds a simulation from a snapshot written by Save
+func LoadSimulation(r io.Reader) (*Simulation, error) {
+	sr := &snapshotReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+	magic := sr.bytes(len(snapshotMagic))
+	if sr.err == nil && string(magic) != snapshotMagic {
+		return nil, errors.New("smoothlife: not a snapshot")
+	}
+	if version := sr.u16(); sr.err == nil && version != snapshotVersion {
+		return nil, fmt.Errorf("smoothlife: unsupported snapshot version %d", version)
+	}
+	opts := sr.options()
+	steps := sr.u64()
+	draws := sr.u64()
+	channels := sr.count()
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("smoothlife: snapshot holds invalid options: %w", err)
+	}
+	if channels != opts.channels() {
+		return nil, fmt.Errorf("smoothlife: snapshot holds %d fields for %d channels", channels, opts.channels())
+	}
+	fields := make([][]float64, channels)
+	for c := range fields {
+		if sr.err != nil {
+			break
+		}
+		fields[c] = sr.f64s(opts.Width * opts.Height)
+	}
+	sum := sr.crc.Sum32()
+	var stored uint32
+	if sr.err == nil {
+		sr.err = binary.Read(sr.r, binary.LittleEndian, &stored)
+	}
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	if stored != sum {
+		return nil, errSnapshotChecksum
+	}
+
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		return nil, err
+	}
+	for c, data := range fields {
+		copy(sim.field[c].RawMatrix().Data, data)
+	}
+	sim.steps = steps
+	sim.source.skip(draws)
+	return sim, nil
+}
+
+// Load replaces the simulation with the one stored in a snapshot written by Save,
+// in place like Adopt, so that method values taken earlier act on the loaded state.
+// The simulation is left untouched if the snapshot cannot be read.
+func (s *Simulation) Load(r io.Reader) error {
+	loaded, err := LoadSimulation(r)
+	if err != nil {
+		return err
+	}
+	*s.SmoothLife = *loaded.SmoothLife
+	s.options = loaded.options
+	return nil
+}
+
+// snapshotWriter writes little endian values, keeping a running checksum and the
+// first error
+type snapshotWriter struct {
+	w   io.Writer
+	crc hash.Hash32
+	buf [8]byte
+	err error
+}
+
+func (sw *snapshotWriter) bytes(b []byte) {
+	if sw.err != nil {
+		return
+	}
+	sw.crc.Write(b)
+	_, sw.err = sw.w.Write(b)
+}
+
+func (sw *snapshotWriter) u8(v uint8) {
+	sw.buf[0] = v
+	sw.bytes(sw.buf[:1])
+}
+
+func (sw *snapshotWriter) u16(v uint16) {
+	binary.LittleEndian.PutUint16(sw.buf[:2], v)
+	sw.bytes(sw.buf[:2])
+}
+
+func (sw *snapshotWriter) u32(v uint32) {
+	binary.LittleEndian.PutUint32(sw.buf[:4], v)
+	sw.bytes(sw.buf[:4])
+}
+
+func (sw *snapshotWriter) u64(v uint64) {
+	binary.LittleEndian.PutUint64(sw.buf[:8], v)
+	sw.bytes(sw.buf[:8])
+}
+
+func (sw *snapshotWriter) f64(v float64) {
+	sw.u64(math.Float64bits(v))
+}
+
+func (sw *snapshotWriter) f64s(values []float64) {
+	var chunk [512 * 8]byte
+	for len(values) > 0 && sw.err == nil {
+		n := min(len(values), len(chunk)/8)
+		for i, v := range values[:n] {
+			binary.LittleEndian.PutUint64(chunk[i*8:], math.Float64bits(v))
+		}
+		sw.bytes(chunk[:n*8])
+		values = values[n:]
+	}
+}
+
+func (sw *snapshotWriter) lenia(k *LeniaKernel) {
+	if k == nil {
+		sw.u8(0)
+		return
+	}
+	sw.u8(1)
+	sw.f64(k.R)
+	sw.u8(uint8(k.Core))
+	sw.u32(uint32(len(k.B)))
+	sw.f64s(k.B)
+}
+
+func (sw *snapshotWriter) rule(rule Rule) {
+	switch r := rule.(type) {
+	case *BasicRules:
+		sw.rule(*r)
+	case *LeniaRules:
+		sw.rule(*r)
+	case BasicRules:
+		sw.u8(snapshotRuleBasic)
+		sw.f64s([]float64{r.B1, r.B2, r.D1, r.D2, r.N, r.M})
+		sw.u8(uint8(r.AliveSigmoid))
+		sw.u8(uint8(r.IntervalSigmoid))
+		sw.u8(uint8(r.MixSigmoid))
+	case LeniaRules:
+		sw.u8(snapshotRuleLenia)
+		sw.f64s([]float64{r.Mu, r.Sigma, r.T})
+		sw.u8(uint8(r.Growth))
+	default:
+		if sw.err == nil {
+			sw.err = fmt.Errorf("smoothlife: cannot save a simulation using a %T rule", rule)
+		}
+	}
+}
+
+func (sw *snapshotWriter) options(o Options) {
+	sw.u32(uint32(o.Width))
+	sw.u32(uint32(o.Height))
+	sw.f64s([]float64{o.InnerRadius, o.OuterRadius, o.LogRes})
+	sw.lenia(o.Lenia)
+	sw.rule(o.Rule)
+	sw.u8(uint8(o.TimeStep.Mode))
+	sw.u8(uint8(o.TimeStep.Integrator))
+	sw.f64(o.TimeStep.Dt)
+
+	sw.u32(uint32(o.Channels))
+	sw.u32(uint32(len(o.Kernels)))
+	for _, k := range o.Kernels {
+		sw.u32(uint32(k.Source))
+		sw.f64s([]float64{k.InnerRadius, k.OuterRadius, k.LogRes})
+		sw.lenia(k.Lenia)
+	}
+	sw.u32(uint32(len(o.Weights)))
+	for _, row := range o.Weights {
+		sw.u32(uint32(len(row)))
+		sw.f64s(row)
+	}
+	sw.u32(uint32(len(o.ChannelRules)))
+	for _, rule := range o.ChannelRules {
+		sw.rule(rule)
+	}
+
+	sw.u8(uint8(o.Boundary))
+	sw.f64(o.BoundaryValue)
+	sw.u64(uint64(o.Seed))
+}
+
+// snapshotReader mirrors snapshotWriter
+type snapshotReader struct {
+	r   io.Reader
+	crc hash.Hash32
+	err error
+}
+
+func (sr *snapshotReader) bytes(n int) []byte {
+	if sr.err != nil {
+		return make([]byte, n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		sr.err = fmt.Errorf("smoothlife: reading snapshot: %w", err)
+	}
+	sr.crc.Write(b)
+	return b
+}
+
+func (sr *snapshotReader) u8() uint8 {
+	return sr.bytes(1)[0]
+}
+
+func (sr *snapshotReader) u16() uint16 {
+	return binary.LittleEndian.Uint16(sr.bytes(2))
+}
+
+func (sr *snapshotReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(sr.bytes(4))
+}
+
+func (sr *snapshotReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(sr.bytes(8))
+}
+
+func (sr *snapshotReader) f64() float64 {
+	return math.Float64frombits(sr.u64())
+}
+
+// count reads a length, rejecting implausibly large ones
+func (sr *snapshotReader) count() int {
+	n := sr.u32()
+	if sr.err == nil && n > maxSnapshotCount {
+		sr.err = fmt.Errorf("smoothlife: snapshot length %d is too large", n)
+	}
+	if sr.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// f64s reads n values, growing the slice as they arrive rather than trusting n.
+// It returns fewer than n after an error.
+func (sr *snapshotReader) f64s(n int) []float64 {
+	values := make([]float64, 0, min(n, 512))
+	for len(values) < n && sr.err == nil {
+		b := sr.bytes(min(n-len(values), 512) * 8)
+		if sr.err != nil {
+			break
+		}
+		for i := 0; i < len(b); i += 8 {
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(b[i:])))
+		}
+	}
+	return values
+}
+
+func (sr *snapshotReader) lenia() *LeniaKernel {
+	if sr.u8() == 0 {
+		return nil
+	}
+	k := &LeniaKernel{R: sr.f64(), Core: KernelCore(sr.u8())}
+	k.B = sr.f64s(sr.count())
+	return k
+}
+
+func (sr *snapshotReader) rule() Rule {
+	switch tag := sr.u8(); tag {
+	case snapshotRuleBasic:
+		values := sr.f64s(6)
+		if sr.err != nil {
+			return nil
+		}
+		return BasicRules{
+			B1: values[0], B2: values[1], D1: values[2], D2: values[3], N: values[4], M: values[5],
+			AliveSigmoid:    Sigmoid(sr.u8()),
+			IntervalSigmoid: Sigmoid(sr.u8()),
+			MixSigmoid:      Sigmoid(sr.u8()),
+		}
+	case snapshotRuleLenia:
+		values := sr.f64s(3)
+		if sr.err != nil {
+			return nil
+		}
+		return LeniaRules{Mu: values[0], Sigma: values[1], T: values[2], Growth: GrowthFunc(sr.u8())}
+	default:
+		if sr.err == nil {
+			sr.err = fmt.Errorf("smoothlife: unknown rule tag %d in snapshot", tag)
+		}
+		return nil
+	}
+}
+
+func (sr *snapshotReader) options() Options {
+	var o Options
+	o.Width = int(sr.u32())
+	o.Height = int(sr.u32())
+	if sr.err == nil && (o.Width > maxSnapshotCells || o.Height > maxSnapshotCells || o.Width*o.Height > maxSnapshotCells) {
+		sr.err = fmt.Errorf("smoothlife: snapshot grid %dx%d is too large", o.Width, o.Height)
+	}
+	o.InnerRadius = sr.f64()
+	o.OuterRadius = sr.f64()
+	o.LogRes = sr.f64()
+	o.Lenia = sr.lenia()
+	o.Rule = sr.rule()
+	o.TimeStep.Mode = TimeStepMode(sr.u8())
+	o.TimeStep.Integrator = Integrator(sr.u8())
+	o.TimeStep.Dt = sr.f64()
+
+	o.Channels = sr.count()
+	if n := sr.count(); n > 0 {
+		o.Kernels = make([]Kernel, n)
+		for i := range o.Kernels {
+			o.Kernels[i].Source = int(sr.u32())
+			o.Kernels[i].InnerRadius = sr.f64()
+			o.Kernels[i].OuterRadius = sr.f64()
+			o.Kernels[i].LogRes = sr.f64()
+			o.Kernels[i].Lenia = sr.lenia()
+		}
+	}
+	if n := sr.count(); n > 0 {
+		o.Weights = make([][]float64, n)
+		for i := range o.Weights {
+			o.Weights[i] = sr.f64s(sr.count())
+		}
+	}
+	if n := sr.count(); n > 0 {
+		o.ChannelRules = make([]Rule, n)
+		for i := range o.ChannelRules {
+			o.ChannelRules[i] = sr.rule()
+		}
+	}
+
+	o.Boundary = Boundary(sr.u8())
+	o.BoundaryValue = sr.f64()
+	o.Seed = int64(sr.u64())
+	return o
+}
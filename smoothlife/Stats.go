@@ -0,0 +1,43 @@
+package smoothlife
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Stats summarises a field
+type Stats struct {
+	// Mass is the mean value of the cells
+	Mass float64
+	// Alive is the fraction of cells above 0.5
+	Alive float64
+	Min   float64
+	Max   float64
+}
+
+// FieldStats measures a field
+func FieldStats(field *mat.Dense) Stats {
+	raw := field.RawMatrix()
+	s := Stats{Min: math.Inf(1), Max: math.Inf(-1)}
+	var alive int
+	for i := 0; i < raw.Rows; i++ {
+		for _, v := range raw.Data[i*raw.Stride : i*raw.Stride+raw.Cols] {
+			s.Mass += v
+			if v > 0.5 {
+				alive++
+			}
+			s.Min = math.Min(s.Min, v)
+			s.Max = math.Max(s.Max, v)
+		}
+	}
+	cells := float64(raw.Rows * raw.Cols)
+	s.Mass /= cells
+	s.Alive = float64(alive) / cells
+	return s
+}
+
+// Stats measures channel c
+func (sl *SmoothLife) Stats(c int) Stats {
+	return FieldStats(sl.field[c])
+}
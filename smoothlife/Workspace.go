@@ -0,0 +1,107 @@
+package smoothlife
+
+import (
+	"gonum.org/v1/gonum/mat"
+)
+
+// workspace holds every intermediate buffer of a step. It is allocated once per
+// simulation, so that Step does not allocate.
+type workspace struct {
+	// pad is the border added around the field for non-periodic boundaries, padded
+	// holds a padded field or convolution
+	pad    int
+	padded []float64
+
+	fft *rfft2
+	// spectra holds the half spectrum of each channel, transformed marks the ones
+	// computed during the current convolution
+	spectra     [][]complex128
+	transformed []bool
+	product     []complex128
+
+	// Densities measured by each kernel
+	kernelN []*mat.Dense
+	kernelM []*mat.Dense
+	// Densities seen by the rule of each channel
+	n []*mat.Dense
+	m []*mat.Dense
+	// Output of the rules and the field being written by the current step
+	s    []*mat.Dense
+	next []*mat.Dense
+
+	// Runge-Kutta stages, allocated on the first smooth step
+	k     [4][]*mat.Dense
+	stage []*mat.Dense
+}
+
+func newWorkspace(channels int, kernels int, width int, height int, pad int) *workspace {
+	fft := newRFFT2(height+2*pad, width+2*pad)
+	ws := &workspace{
+		pad:         pad,
+		fft:         fft,
+		spectra:     make([][]complex128, channels),
+		transformed: make([]bool, channels),
+		product:     make([]complex128, fft.half*fft.rows),
+		kernelN:     newDenses(kernels, width, height),
+		kernelM:     newDenses(kernels, width, height),
+		n:           newDenses(channels, width, height),
+		m:           newDenses(channels, width, height),
+		s:           newDenses(channels, width, height),
+		next:        newDenses(channels, width, height),
+	}
+	for c := range ws.spectra {
+		ws.spectra[c] = make([]complex128, fft.half*fft.rows)
+	}
+	if pad > 0 {
+		ws.padded = make([]float64, fft.rows*fft.cols)
+	}
+	return ws
+}
+
+// ensureStages allocates the buffers used by the smooth time step modes
+func (ws *workspace) ensureStages() {
+	if ws.stage != nil {
+		return
+	}
+	channels := len(ws.next)
+	rows, cols := ws.next[0].Dims()
+	for i := range ws.k {
+		ws.k[i] = newDenses(channels, cols, rows)
+	}
+	ws.stage = newDenses(channels, cols, rows)
+}
+
+func newDenses(count int, width int, height int) []*mat.Dense {
+	denses := make([]*mat.Dense, count)
+	for i := range denses {
+		denses[i] = mat.NewDense(height, width, nil)
+	}
+	return denses
+}
+
+// transform fills spectrum with the half spectrum of field, padded according to
+// the boundary condition
+func (sl *SmoothLife) transform(spectrum []complex128, field *mat.Dense) {
+	ws := sl.ws
+	if ws.pad == 0 {
+		ws.fft.forward(spectrum, field.RawMatrix().Data)
+		return
+	}
+	sl.pad(ws.padded, field)
+	ws.fft.forward(spectrum, ws.padded)
+}
+
+// convolveSpectrum writes into dst the inverse transform of spectrum multiplied
+// element-wise by the half spectrum of a kernel
+func (sl *SmoothLife) convolveSpectrum(dst *mat.Dense, spectrum []complex128, kernel *mat.CDense) {
+	ws := sl.ws
+	for i, v := range kernel.RawCMatrix().Data {
+		ws.product[i] = spectrum[i] * v
+	}
+	if ws.pad == 0 {
+		ws.fft.inverse(dst.RawMatrix().Data, ws.product)
+		return
+	}
+	ws.fft.inverse(ws.padded, ws.product)
+	sl.crop(dst, ws.padded)
+}
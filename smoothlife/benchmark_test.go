@@ -1,4 +1,4 @@
-package main
+package smoothlife
 
 // run benchmarks with: go test -bench=. -benchmem
 
@@ -29,3 +29,31 @@ func BenchmarkLogisticThresholdDenseElementWise(b *testing.B) {
 		LogisticThresholdDenseElementWise(x, x0, alpha)
 	}
 }
+
+func BenchmarkSimulationStep(b *testing.B) {
+	sim, err := ConstructSimulation(DefaultOptions())
+	if err != nil {
+		b.Fatal(err)
+	}
+	sim.AddSpeckles()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.Step()
+	}
+}
+
+func BenchmarkLeniaStep(b *testing.B) {
+	sim, err := ConstructSimulation(LeniaOptions())
+	if err != nil {
+		b.Fatal(err)
+	}
+	sim.AddSpeckles()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.Step()
+	}
+}
@@ -0,0 +1,81 @@
+package smoothlife
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// boundaryDensities fills a 40x40 field with fill, sets the cells in column 0 to
+// edge, and returns the annulus density n after one convolution
+func boundaryDensities(t *testing.T, boundary Boundary, value float64, fill float64, edge float64) *mat.Dense {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 40, 40
+	opts.InnerRadius, opts.OuterRadius = 2, 5
+	opts.LogRes = 4
+	opts.Boundary = boundary
+	opts.BoundaryValue = value
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := sim.Field()
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 40; j++ {
+			field.Set(i, j, fill)
+		}
+		field.Set(i, 0, edge)
+	}
+	sim.convolve(sim.field)
+	return mat.DenseCopyOf(sim.ws.n[0])
+}
+
+func TestBoundaries(t *testing.T) {
+	t.Run("Periodic wraps", func(t *testing.T) {
+		n := boundaryDensities(t, BoundaryPeriodic, 0, 0, 1)
+		if got := n.At(20, 38); got <= 0.01 {
+			t.Errorf("n next to the wrapped edge = %v; want the edge column to be visible", got)
+		}
+	})
+	t.Run("Zero does not wrap", func(t *testing.T) {
+		n := boundaryDensities(t, BoundaryZero, 0.7, 0, 1)
+		if got := n.At(20, 38); !almostEqual(got, 0, 1e-9) {
+			t.Errorf("n next to the far edge = %v; want 0", got)
+		}
+		if got := n.At(20, 3); got <= 0.01 {
+			t.Errorf("n next to the live column = %v; want it to be visible", got)
+		}
+	})
+	t.Run("Fixed feeds the edges", func(t *testing.T) {
+		n := boundaryDensities(t, BoundaryFixed, 1, 0, 0)
+		if got := n.At(20, 38); got <= 0.1 {
+			t.Errorf("n next to a live boundary = %v; want a positive density", got)
+		}
+		if got := n.At(20, 20); !almostEqual(got, 0, 1e-9) {
+			t.Errorf("n in the middle of an empty field = %v; want 0", got)
+		}
+	})
+	t.Run("Reflect preserves a uniform field", func(t *testing.T) {
+		n := boundaryDensities(t, BoundaryReflect, 0, 0.5, 0.5)
+		for _, cell := range [][2]int{{0, 0}, {20, 39}, {39, 20}, {20, 20}} {
+			if got := n.At(cell[0], cell[1]); !almostEqual(got, 0.5, 1e-6) {
+				t.Errorf("n at %v = %v; want 0.5", cell, got)
+			}
+		}
+	})
+}
+
+func TestReflectIndex(t *testing.T) {
+	cases := []struct {
+		i, size, expected int
+	}{
+		{0, 5, 0}, {4, 5, 4}, {-1, 5, 0}, {-2, 5, 1}, {5, 5, 4}, {6, 5, 3}, {-7, 5, 3}, {12, 5, 2},
+	}
+
+	for _, tc := range cases {
+		if result := reflectIndex(tc.i, tc.size); result != tc.expected {
+			t.Errorf("reflectIndex(%v, %v) = %v; want %v", tc.i, tc.size, result, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package smoothlife
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestBrush(t *testing.T) {
+	brush := Brush{Radius: 3, Intensity: 0.5, LogRes: 8}
+	cases := []struct {
+		name  string
+		paint func(field *mat.Dense)
+		i, j  int
+		want  float64
+		// atLeast accepts anything above want, where dabs overlap
+		atLeast bool
+	}{
+		{"Paint centre", func(f *mat.Dense) { brush.Paint(f, 10, 10) }, 10, 10, 0.5, false},
+		{"Paint twice", func(f *mat.Dense) { brush.Paint(f, 10, 10); brush.Paint(f, 10, 10) }, 10, 10, 0.75, false},
+		{"Paint outside", func(f *mat.Dense) { brush.Paint(f, 10, 10) }, 10, 16, 0, false},
+		{"Wraps around", func(f *mat.Dense) { brush.Paint(f, 0, 0) }, 19, 19, 0.5, false},
+		{"Wider than the field", func(f *mat.Dense) { Brush{Radius: 15, Intensity: 0.5, LogRes: 8}.Paint(f, 10.5, 10.5) }, 0, 0, 0.5, false},
+		{"Erase", func(f *mat.Dense) { f.Set(10, 10, 1); brush.Erase(f, 10, 10) }, 10, 10, 0.5, false},
+		{"Stroke fills the gap", func(f *mat.Dense) { brush.Stroke(f, 2, 10, 18, 10, false) }, 10, 10, 0.5, true},
+		{"Stroke erases", func(f *mat.Dense) { f.Set(10, 10, 1); brush.Stroke(f, 2, 10, 18, 10, true) }, 10, 10, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			field := mat.NewDense(20, 20, nil)
+			tc.paint(field)
+			got := field.At(tc.i, tc.j)
+			if tc.atLeast {
+				if got < tc.want {
+					t.Errorf("cell (%d, %d) = %v; want at least %v", tc.i, tc.j, got, tc.want)
+				}
+				return
+			}
+			if !almostEqual(got, tc.want, 0.05) {
+				t.Errorf("cell (%d, %d) = %v; want %v", tc.i, tc.j, got, tc.want)
+			}
+		})
+	}
+}
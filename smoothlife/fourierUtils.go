@@ -0,0 +1,76 @@
+package smoothlife
+
+import (
+	"gonum.org/v1/gonum/dsp/fourier"
+	"gonum.org/v1/gonum/mat"
+)
+
+// rfft2 computes 2D transforms of row-major real data. The transform of a real
+// field is Hermitian, so only the rows x (cols/2+1) half spectrum is stored.
+// All scratch space is allocated up front, so transforms do not allocate.
+type rfft2 struct {
+	rows   int
+	cols   int
+	half   int
+	rowFFT *fourier.FFT
+	colFFT *fourier.CmplxFFT
+	column []complex128
+}
+
+func newRFFT2(rows int, cols int) *rfft2 {
+	return &rfft2{
+		rows:   rows,
+		cols:   cols,
+		half:   cols/2 + 1,
+		rowFFT: fourier.NewFFT(cols),
+		colFFT: fourier.NewCmplxFFT(rows),
+		column: make([]complex128, rows),
+	}
+}
+
+// forward writes the unnormalised half spectrum of src into dst
+func (f *rfft2) forward(dst []complex128, src []float64) {
+	for i := 0; i < f.rows; i++ {
+		f.rowFFT.Coefficients(dst[i*f.half:(i+1)*f.half], src[i*f.cols:(i+1)*f.cols])
+	}
+	for j := 0; j < f.half; j++ {
+		for i := 0; i < f.rows; i++ {
+			f.column[i] = dst[i*f.half+j]
+		}
+		f.colFFT.Coefficients(f.column, f.column)
+		for i := 0; i < f.rows; i++ {
+			dst[i*f.half+j] = f.column[i]
+		}
+	}
+}
+
+// inverse writes the real sequence described by the half spectrum src into dst,
+// normalised so that inverse undoes forward. src is used as scratch space.
+func (f *rfft2) inverse(dst []float64, src []complex128) {
+	for j := 0; j < f.half; j++ {
+		for i := 0; i < f.rows; i++ {
+			f.column[i] = src[i*f.half+j]
+		}
+		f.colFFT.Sequence(f.column, f.column)
+		for i := 0; i < f.rows; i++ {
+			src[i*f.half+j] = f.column[i]
+		}
+	}
+	scale := 1 / float64(f.rows*f.cols)
+	for i := 0; i < f.rows; i++ {
+		row := dst[i*f.cols : (i+1)*f.cols]
+		f.rowFFT.Sequence(row, src[i*f.half:(i+1)*f.half])
+		for j := range row {
+			row[j] *= scale
+		}
+	}
+}
+
+// rfft2dense returns the half spectrum of a real matrix
+func rfft2dense(input *mat.Dense) *mat.CDense {
+	r, c := input.Dims()
+	f := newRFFT2(r, c)
+	output := mat.NewCDense(r, f.half, nil)
+	f.forward(output.RawCMatrix().Data, mat.DenseCopyOf(input).RawMatrix().Data)
+	return output
+}
@@ -0,0 +1,124 @@
+package smoothlife
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestGenerators(t *testing.T) {
+	for _, name := range GeneratorNames() {
+		t.Run(name, func(t *testing.T) {
+			g, err := NewGenerator(name, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validateGenerator(g); err != nil {
+				t.Fatalf("default parameters are invalid: %v", err)
+			}
+			a := mat.NewDense(48, 64, nil)
+			b := mat.NewDense(48, 64, nil)
+			g.Generate(a, rand.New(rand.NewSource(1)))
+			g.Generate(b, rand.New(rand.NewSource(1)))
+
+			if !mat.Equal(a, b) {
+				t.Error("the same seed drew different fields")
+			}
+			if SumDenseMatrix(a) == 0 {
+				t.Error("nothing was drawn")
+			}
+			if min, max := mat.Min(a), mat.Max(a); min < 0 || max > 1 {
+				t.Errorf("values span [%v, %v]; want them within [0, 1]", min, max)
+			}
+		})
+	}
+}
+
+func TestGeneratorsLayer(t *testing.T) {
+	field := mat.NewDense(32, 32, nil)
+	field.Set(0, 0, 0.9)
+	UniformNoise{Min: 0, Max: 0.5}.Generate(field, rand.New(rand.NewSource(1)))
+	if field.At(0, 0) != 0.9 {
+		t.Errorf("noise overwrote a larger value with %v", field.At(0, 0))
+	}
+}
+
+func TestCentredBlob(t *testing.T) {
+	field := mat.NewDense(40, 40, nil)
+	CentredBlob{Radius: 5, Value: 1, LogRes: 4}.Generate(field, nil)
+	cases := []struct {
+		name string
+		i, j int
+		want float64
+	}{
+		{"Centre", 20, 20, 1},
+		{"Edge", 20, 25, 0.5},
+		{"Corner", 0, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := field.At(tc.i, tc.j); !almostEqual(got, tc.want, 1e-6) {
+			t.Errorf("%s = %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSparseDiscsDensity(t *testing.T) {
+	// At a fixed density, quadrupling the area quadruples the number of discs and
+	// so roughly the mass
+	mass := func(size int) float64 {
+		field := mat.NewDense(size, size, nil)
+		SparseDiscs{Density: 0.1, Radius: 2, Value: 1, LogRes: 8}.Generate(field, rand.New(rand.NewSource(3)))
+		return SumDenseMatrix(field)
+	}
+	small, large := mass(64), mass(128)
+	if ratio := large / small; ratio < 3 || ratio > 5 {
+		t.Errorf("mass grew by %v for four times the area; want about 4", ratio)
+	}
+}
+
+func TestParseGenerator(t *testing.T) {
+	cases := []struct {
+		spec string
+		want Generator
+		err  string
+	}{
+		{"speckles", &Speckles{Count: 25, Size: 10, Value: 1}, ""},
+		{"discs:count=3,radius=2.5", &Discs{Count: 3, Radius: 2.5, Value: 1}, ""},
+		{"value-noise:octaves=2, Persistence=0.25", &ValueNoise{Scale: 20, Octaves: 2, Persistence: 0.25, Min: 0, Max: 1}, ""},
+		{"squares", nil, "unknown generator"},
+		{"discs:colour=3", nil, "no parameter"},
+		{"discs:count=many", nil, "count"},
+		{"discs:count", nil, "key=value"},
+		{"blobs:sigma=0", nil, "must be positive"},
+		{"discs:count=0", nil, "disc count must be positive"},
+		{"discs:count=-1", nil, "disc count must be positive"},
+		{"discs:radius=-2", nil, "disc radius must be positive"},
+		{"blob:radius=0", nil, "blob radius must be positive"},
+		{"noise:min=0.2,max=0.6", &UniformNoise{Min: 0.2, Max: 0.6}, ""},
+		{"noise:min=0.8,max=0.2", nil, "noise range"},
+		{"noise:min=-0.1", nil, "noise range"},
+		{"noise:max=2", nil, "noise range"},
+		{"value-noise:max=1.5", nil, "noise range"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := ParseGenerator(tc.spec, 10)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("ParseGenerator error = %v; want one containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseGenerator = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
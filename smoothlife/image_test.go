@@ -0,0 +1,113 @@
+package smoothlife
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// testImage is a 4x2 image, black on the left half and white on the right
+func testImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 2; x < 4; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	return img
+}
+
+func TestDecodeImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeImage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 2 {
+		t.Errorf("decoded a %v image; want 4x2", img.Bounds())
+	}
+	if _, err := DecodeImage(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("DecodeImage accepted garbage")
+	}
+}
+
+func TestImageSeed(t *testing.T) {
+	cases := []struct {
+		name       string
+		seed       ImageSeed
+		rows, cols int
+		want       []float64
+	}{
+		{
+			"Stretch nearest", ImageSeed{Fit: FitStretch, Filter: ResampleNearest}, 2, 8,
+			[]float64{0, 0, 0, 0, 1, 1, 1, 1, 0, 0, 0, 0, 1, 1, 1, 1},
+		},
+		{
+			"Invert", ImageSeed{Fit: FitStretch, Filter: ResampleNearest, Invert: true}, 1, 2,
+			[]float64{1, 0},
+		},
+		{
+			"Box shrink", ImageSeed{Fit: FitStretch, Filter: ResampleBox}, 1, 1,
+			[]float64{0.5},
+		},
+		{
+			"Threshold", ImageSeed{Fit: FitStretch, Filter: ResampleBox, Threshold: 0.4}, 1, 1,
+			[]float64{1},
+		},
+		{
+			// The 4x2 image covers a 2x2 field at 1x, the middle two columns remain
+			"Cover", ImageSeed{Fit: FitCover, Filter: ResampleNearest}, 2, 2,
+			[]float64{0, 1, 0, 1},
+		},
+		{
+			// Fitted inside a 4x4 field the image occupies the middle two rows
+			"Contain", ImageSeed{Fit: FitContain, Filter: ResampleNearest}, 4, 4,
+			[]float64{0, 0, 0, 0, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 0, 0},
+		},
+		{
+			"Crop", ImageSeed{Fit: FitCrop, Filter: ResampleBilinear}, 1, 2,
+			[]float64{0, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.seed.Image = testImage()
+			if err := tc.seed.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			field := mat.NewDense(tc.rows, tc.cols, nil)
+			tc.seed.Generate(field, nil)
+			for i, want := range tc.want {
+				if got := field.RawMatrix().Data[i]; !almostEqual(got, want, 1e-9) {
+					t.Errorf("cell %d = %v; want %v\n%v", i, got, want, mat.Formatted(field))
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestResamplePreservesConstant(t *testing.T) {
+	src := make([]float64, 7*5)
+	for i := range src {
+		src[i] = 0.3
+	}
+	for filter := range resampleNames {
+		for _, size := range [][2]int{{3, 2}, {13, 11}} {
+			for _, v := range resample(src, 7, 5, size[0], size[1], filter) {
+				if !almostEqual(v, 0.3, 1e-12) {
+					t.Errorf("%v resampling to %v changed a constant image to %v", filter, size, v)
+					break
+				}
+			}
+		}
+	}
+}
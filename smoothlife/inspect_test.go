@@ -0,0 +1,61 @@
+package smoothlife
+
+import (
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestInspect(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 24
+	opts.InnerRadius, opts.OuterRadius = 2, 6
+	opts.Seed = 1
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.AddSpeckles()
+	field := mat.DenseCopyOf(sim.Field())
+
+	layers := map[Layer]*mat.Dense{}
+	for layer := range layerNames {
+		dst := mat.NewDense(opts.Height, opts.Width, nil)
+		if err := sim.Inspect(layer, 0, dst); err != nil {
+			t.Fatalf("Inspect(%v): %v", layer, err)
+		}
+		layers[layer] = dst
+	}
+
+	// Inspecting must not disturb the simulation
+	if !mat.Equal(field, sim.Field()) {
+		t.Error("Inspect changed the field")
+	}
+	// The change the rule would make is what a discrete step makes
+	var want mat.Dense
+	want.Sub(sim.Step(), field)
+	if !mat.EqualApprox(layers[LayerDeltaS], &want, 1e-12) {
+		t.Error("delta-s differs from the change made by the next step")
+	}
+	// The thresholds lie between the birth and survival bounds
+	rules := opts.Rule.(BasicRules)
+	if lo, hi := mat.Min(layers[LayerThreshold1]), mat.Max(layers[LayerThreshold1]); lo < rules.D1-1e-12 || hi > rules.B1+1e-12 {
+		t.Errorf("threshold1 spans [%v, %v]; want it within [%v, %v]", lo, hi, rules.D1, rules.B1)
+	}
+	// The kernels sum to 1 and peak in the middle
+	for _, layer := range []Layer{LayerInnerKernel, LayerAnnulusKernel} {
+		if sum := SumDenseMatrix(layers[layer]); !almostEqual(sum, 1, 1e-9) {
+			t.Errorf("%v sums to %v; want 1", layer, sum)
+		}
+	}
+	if centre := layers[LayerInnerKernel].At(12, 16); centre != mat.Max(layers[LayerInnerKernel]) {
+		t.Errorf("the inner kernel is %v in the middle; want its maximum", centre)
+	}
+
+	sim.SetRule(LeniaRules{Mu: 0.15, Sigma: 0.015, T: 10})
+	err = sim.Inspect(LayerAliveness, 0, mat.NewDense(opts.Height, opts.Width, nil))
+	if err == nil || !strings.Contains(err.Error(), "BasicRules") {
+		t.Errorf("Inspect(aliveness) of a Lenia rule = %v; want an error", err)
+	}
+}
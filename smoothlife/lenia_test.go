@@ -0,0 +1,93 @@
+package smoothlife
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLeniaKernelWeights(t *testing.T) {
+	cases := []struct {
+		name   string
+		kernel LeniaKernel
+	}{
+		{"Single exponential shell", LeniaKernel{R: 10, B: []float64{1}, Core: CoreExponential}},
+		{"Three polynomial shells", LeniaKernel{R: 12, B: []float64{1, 0.5, 0.25}, Core: CorePolynomial}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			weights := tc.kernel.Weights(64, 64)
+			if sum := SumDenseMatrix(weights); !almostEqual(sum, 1, 1e-9) {
+				t.Errorf("kernel weights sum to %v; want 1", sum)
+			}
+			// The kernel is centred on the origin and wraps, so cells beyond R are empty
+			if w := weights.At(32, 32); w != 0 {
+				t.Errorf("weight at distance %v = %v; want 0", math.Sqrt(2)*32, w)
+			}
+			// Each shell peaks halfway through its width, scaled by its weight
+			shellWidth := tc.kernel.R / float64(len(tc.kernel.B))
+			peak := weights.At(0, int(shellWidth/2))
+			for i, b := range tc.kernel.B[1:] {
+				r := int(shellWidth*float64(i+1) + shellWidth/2)
+				if got := weights.At(0, r); !almostEqual(got, peak*b, peak*1e-6) {
+					t.Errorf("shell %d peak = %v; want %v", i+1, got, peak*b)
+				}
+			}
+		})
+	}
+}
+
+func TestLeniaGrowth(t *testing.T) {
+	cases := []struct {
+		name     string
+		growth   GrowthFunc
+		u        float64
+		expected float64
+	}{
+		{"Gaussian at mu", GrowthGaussian, 0.15, 1},
+		{"Gaussian far from mu", GrowthGaussian, 0.9, -1},
+		{"Gaussian at one sigma", GrowthGaussian, 0.165, 2*math.Exp(-0.5) - 1},
+		{"Polynomial at mu", GrowthPolynomial, 0.15, 1},
+		{"Polynomial beyond three sigma", GrowthPolynomial, 0.2, -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.growth.at(tc.u, 0.15, 0.015)
+			if !almostEqual(result, tc.expected, 1e-9) {
+				t.Errorf("%v.at(%v, 0.15, 0.015) = %v; want %v", tc.growth, tc.u, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLeniaSimulation(t *testing.T) {
+	opts := LeniaOptions()
+	opts.Width, opts.Height = 64, 64
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.AddSpeckles()
+	for i := 0; i < 5; i++ {
+		sim.Step()
+	}
+	if !mat.Equal(sim.ws.n[0], sim.ws.m[0]) {
+		t.Error("the densities of a Lenia kernel differ")
+	}
+	r, c := sim.Field().Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if v := sim.Field().At(i, j); v < 0 || v > 1 {
+				t.Fatalf("field(%d, %d) = %v; want a value in [0,1]", i, j, v)
+			}
+		}
+	}
+
+	opts.Rule = LeniaRules{Mu: 0.15, Sigma: 0.015}
+	if _, err := ConstructSimulation(opts); err == nil {
+		t.Error("ConstructSimulation accepted a lenia rule without a time resolution")
+	}
+}
@@ -0,0 +1,213 @@
+package smoothlife
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// npyBytes builds an .npy file the way NumPy would write it
+func npyBytes(header string, order binary.ByteOrder, values ...interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	for _, v := range values {
+		binary.Write(&buf, order, v)
+	}
+	return buf.Bytes()
+}
+
+func TestNPYRoundTrip(t *testing.T) {
+	m := mat.NewDense(2, 3, []float64{math.Pi, -0.0, math.SmallestNonzeroFloat64, math.MaxFloat64, 1.0 / 3, -1e-300})
+	var buf bytes.Buffer
+	if err := WriteNPY(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if headerEnd := bytes.IndexByte(data, '\n') + 1; headerEnd%npyAlign != 0 {
+		t.Errorf("data starts at offset %d; want a multiple of %d", headerEnd, npyAlign)
+	}
+	got, err := ReadNPY(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range m.RawMatrix().Data {
+		if math.Float64bits(got.RawMatrix().Data[i]) != math.Float64bits(want) {
+			t.Errorf("value %d = %v; want exactly %v", i, got.RawMatrix().Data[i], want)
+		}
+	}
+}
+
+// failingWriter accepts n bytes and then fails
+type failingWriter struct {
+	n int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errWriteFailed
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteNPYErrors(t *testing.T) {
+	m := mat.NewDense(64, 64, nil)
+	for _, n := range []int{0, 10, 5000, 64*64*8 - 1} {
+		if err := WriteNPY(&failingWriter{n: n}, m); !errors.Is(err, errWriteFailed) {
+			t.Errorf("WriteNPY to a writer failing after %d bytes = %v; want %v", n, err, errWriteFailed)
+		}
+	}
+}
+
+func TestReadNPY(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want *mat.Dense
+		err  string
+	}{
+		{
+			"C order",
+			npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 2), }\n", binary.LittleEndian, []float64{1, 2, 3, 4}),
+			mat.NewDense(2, 2, []float64{1, 2, 3, 4}), "",
+		},
+		{
+			"Fortran order",
+			npyBytes("{'descr': '<f8', 'fortran_order': True, 'shape': (2, 3), }\n", binary.LittleEndian, []float64{1, 4, 2, 5, 3, 6}),
+			mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}), "",
+		},
+		{
+			"Big endian float32",
+			npyBytes("{'descr': '>f4', 'fortran_order': False, 'shape': (3,), }\n", binary.BigEndian, []float32{0.5, 1, 2}),
+			mat.NewDense(1, 3, []float64{0.5, 1, 2}), "",
+		},
+		{
+			"Scalar",
+			npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (), }\n", binary.LittleEndian, 7.0),
+			mat.NewDense(1, 1, []float64{7}), "",
+		},
+		{
+			"Integers",
+			npyBytes("{'descr': '<i8', 'fortran_order': False, 'shape': (1,), }\n", binary.LittleEndian, int64(1)),
+			nil, "dtype '<i8'",
+		},
+		{
+			"Three dimensions",
+			npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (1, 1, 1), }\n", binary.LittleEndian, 1.0),
+			nil, "3 dimensional",
+		},
+		{
+			"Truncated",
+			npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 2), }\n", binary.LittleEndian, []float64{1, 2, 3}),
+			nil, "unexpected EOF",
+		},
+		{
+			"Shape overflowing the cell count",
+			npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (4294967296, 4294967296), }\n", binary.LittleEndian, 1.0),
+			nil, "too large",
+		},
+		{"Not NumPy", []byte("P6 1 1 255 abc"), nil, "not an npy file"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ReadNPY(bytes.NewReader(tc.data))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("ReadNPY error = %v; want one containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !mat.Equal(got, tc.want) {
+				t.Errorf("ReadNPY = %v; want %v", mat.Formatted(got), mat.Formatted(tc.want))
+			}
+		})
+	}
+}
+
+func TestSimulationNPZ(t *testing.T) {
+	for i, opts := range snapshotOptions() {
+		sim, err := ConstructSimulation(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.AddSpecklesFrom(rand.New(rand.NewSource(int64(i))))
+		sim.Step()
+
+		var buf bytes.Buffer
+		if err := sim.SaveNPZ(&buf); err != nil {
+			t.Fatal(err)
+		}
+		arrays, err := ReadNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(arrays) != len(sim.arrayNames()) {
+			t.Errorf("options %d: archive holds %d arrays; want %d", i, len(arrays), len(sim.arrayNames()))
+		}
+
+		// A fresh simulation loaded from the archive must carry on identically
+		other, err := ConstructSimulation(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := other.LoadNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+			t.Fatal(err)
+		}
+		sim.Step()
+		other.Step()
+		for c := 0; c < sim.Channels(); c++ {
+			if !mat.Equal(sim.Channel(c), other.Channel(c)) {
+				t.Errorf("options %d: channel %d differs after loading the archive", i, c)
+			}
+		}
+	}
+}
+
+func TestSetArrays(t *testing.T) {
+	opts := snapshotOptions()[0]
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arrays := sim.Arrays()
+
+	cases := []struct {
+		name   string
+		arrays map[string]*mat.Dense
+		err    string
+	}{
+		{"Field only", map[string]*mat.Dense{"field": arrays["field"]}, ""},
+		{"Wrong size", map[string]*mat.Dense{"field": mat.NewDense(2, 2, nil)}, "2x2"},
+		{"Inner without annulus", map[string]*mat.Dense{"inner": arrays["inner"]}, "set together"},
+		{"Unknown", map[string]*mat.Dense{"field_1": arrays["field"]}, "unknown array"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sim.SetArrays(tc.arrays)
+			if tc.err == "" && err != nil {
+				t.Errorf("SetArrays = %v", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Errorf("SetArrays error = %v; want one containing %q", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package smoothlife
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPresets(t *testing.T) {
+	for _, name := range PresetNames() {
+		t.Run(name, func(t *testing.T) {
+			p, err := LookupPreset(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base := DefaultOptions()
+			base.Width, base.Height = 64, 64
+			base.Seed = 1
+			opts := p.Apply(base)
+			if opts.InnerRadius != p.InnerRadius || opts.OuterRadius != p.OuterRadius || opts.Rule != p.Rule || opts.TimeStep != p.TimeStep {
+				t.Errorf("Apply gave %+v", opts)
+			}
+			sim, err := ConstructSimulation(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sim.AddSpeckles()
+			for i := 0; i < 3; i++ {
+				sim.Step()
+			}
+			if low, high := mat.Min(sim.Field()), mat.Max(sim.Field()); !(low >= 0 && high <= 1) {
+				t.Errorf("field left [0,1]: [%v, %v]", low, high)
+			}
+		})
+	}
+
+	if _, err := LookupPreset("missing"); err == nil {
+		t.Error("LookupPreset of an unknown name succeeded")
+	}
+}
+
+func TestPresetValidation(t *testing.T) {
+	valid := Preset{Name: "valid", InnerRadius: 3, OuterRadius: 9, Rule: paperRules, TimeStep: TimeStep{Mode: Discrete}}
+	cases := []struct {
+		name   string
+		modify func(p *Preset)
+	}{
+		{"No name", func(p *Preset) { p.Name = "" }},
+		{"Outer inside inner", func(p *Preset) { p.OuterRadius = 2 }},
+		{"Unknown sigmoid", func(p *Preset) { p.Rule.MixSigmoid = Sigmoid(99) }},
+		{"Smooth without dt", func(p *Preset) { p.TimeStep = TimeStep{Mode: SmoothRelax} }},
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := valid
+			tc.modify(&p)
+			if err := p.Validate(); err == nil {
+				t.Errorf("Validate(%+v) succeeded", p)
+			}
+		})
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 48, 32
+	opts.InnerRadius, opts.OuterRadius = 3, 9
+	opts.Seed = 5
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.AddSpeckles()
+	sim.Step()
+	field := mat.DenseCopyOf(sim.Field())
+
+	// A simulation built with the new kernel around the same field
+	opts.InnerRadius, opts.OuterRadius = 4, 12
+	opts.Boundary = BoundaryReflect
+	want, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Field().Copy(field)
+
+	if err := sim.Reconfigure(opts); err != nil {
+		t.Fatal(err)
+	}
+	if sim.StepCount() != 1 || sim.Seed() != 5 || sim.Options().OuterRadius != 12 {
+		t.Errorf("reconfigured to step %d, seed %d and %+v", sim.StepCount(), sim.Seed(), sim.Options())
+	}
+	if !mat.Equal(sim.Field(), field) {
+		t.Error("the field did not carry over")
+	}
+	sim.Step()
+	want.Step()
+	if !mat.EqualApprox(sim.Field(), want.Field(), 1e-12) {
+		t.Error("the reconfigured simulation steps differently from one built with its options")
+	}
+
+	opts.Width = 64
+	if err := sim.Reconfigure(opts); err == nil {
+		t.Error("Reconfigure to another size succeeded")
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 32
+	opts.InnerRadius, opts.OuterRadius = 2, 6
+	opts.Seed = 9
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twin, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*Simulation{sim, twin} {
+		s.AddSpeckles()
+		s.Step()
+	}
+
+	// The replacement is built without a seed, as on a background goroutine
+	opts.OuterRadius, opts.Seed = 8, 0
+	next, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := mat.DenseCopyOf(sim.Field())
+	engine, clear := sim.SmoothLife, sim.Clear
+	if err := sim.Adopt(next); err != nil {
+		t.Fatal(err)
+	}
+	if sim.SmoothLife != engine {
+		t.Error("Adopt replaced the engine instead of changing it in place")
+	}
+	if sim.Seed() != 9 || sim.Options().Seed != 9 || sim.Options().OuterRadius != 8 || sim.StepCount() != 1 {
+		t.Errorf("adopted seed %d, options %+v at step %d", sim.Seed(), sim.Options(), sim.StepCount())
+	}
+	if !mat.Equal(sim.Field(), field) {
+		t.Error("the field did not carry over")
+	}
+	if sim.Rand().Int63() != twin.Rand().Int63() {
+		t.Error("the random source did not carry over")
+	}
+	// A method value taken before the swap acts on the adopted kernels
+	clear()
+	if mat.Sum(sim.Field()) != 0 {
+		t.Error("a method value taken before Adopt missed the simulation")
+	}
+
+	opts.Width = 16
+	small, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Adopt(small); err == nil {
+		t.Error("Adopt of another size succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package smoothlife
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestColormap(t *testing.T) {
+	custom, err := ParseColormap("gradient:#000,#ff8800,#ffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		cm   *Colormap
+		v    float64
+		want color.RGBA
+	}{
+		{"Grayscale low", Grayscale, 0, color.RGBA{0, 0, 0, 0xff}},
+		{"Grayscale high", Grayscale, 1, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{"Clamped below", Viridis, -3, color.RGBA{0x44, 0x01, 0x54, 0xff}},
+		{"Clamped above", Magma, 7, color.RGBA{0xfc, 0xfd, 0xbf, 0xff}},
+		{"Cyclic", Twilight, 1, Twilight.At(0)},
+		{"Custom middle", custom, 0.5, color.RGBA{0xff, 0x88, 0x00, 0xff}},
+	}
+	for _, tc := range cases {
+		// The lookup table puts stops within a step of where they belong
+		if got := tc.cm.At(tc.v); !closeColor(got, tc.want, 2) {
+			t.Errorf("%s: At(%v) = %v; want %v", tc.name, tc.v, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"jet", "gradient:#fff", "gradient:#fff,orange", "gradient:#12345,#000"} {
+		if _, err := ParseColormap(spec); err == nil {
+			t.Errorf("ParseColormap(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 24
+	opts.InnerRadius, opts.OuterRadius = 2, 6
+	opts.Seed = 1
+	for mode := range renderModeNames {
+		t.Run(mode.String(), func(t *testing.T) {
+			sim, err := ConstructSimulation(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sim.AddSpeckles()
+			r := &Renderer{Mode: mode, Colormap: Viridis}
+			img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+			r.Render(img, sim.SmoothLife)
+			sim.Step()
+			r.Render(img, sim.SmoothLife)
+
+			for i := 3; i < len(img.Pix); i += 4 {
+				if img.Pix[i] != 0xff {
+					t.Fatalf("pixel %d has alpha %#x; want opaque", i/4, img.Pix[i])
+				}
+			}
+			if mode == RenderRate {
+				// A cell that did not change sits in the middle of the map
+				for y := 0; y < opts.Height; y++ {
+					for x := 0; x < opts.Width; x++ {
+						if r.change.At(y, x) == 0 && img.RGBAAt(x, y) != Viridis.At(0.5) {
+							t.Fatalf("unchanged cell (%d, %d) is %v; want %v", x, y, img.RGBAAt(x, y), Viridis.At(0.5))
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHSV(t *testing.T) {
+	cases := []struct {
+		h, s, v float64
+		want    color.RGBA
+	}{
+		{0, 1, 1, color.RGBA{0xff, 0, 0, 0xff}},
+		{1.0 / 3, 1, 1, color.RGBA{0, 0xff, 0, 0xff}},
+		{2.0 / 3, 1, 1, color.RGBA{0, 0, 0xff, 0xff}},
+		{0.5, 0, 0.5, color.RGBA{0x80, 0x80, 0x80, 0xff}},
+	}
+	for _, tc := range cases {
+		if got := hsv(tc.h, tc.s, tc.v); got != tc.want {
+			t.Errorf("hsv(%v, %v, %v) = %v; want %v", tc.h, tc.s, tc.v, got, tc.want)
+		}
+	}
+}
+
+func closeColor(a color.RGBA, b color.RGBA, tolerance int) bool {
+	near := func(x, y uint8) bool {
+		d := int(x) - int(y)
+		return -tolerance <= d && d <= tolerance
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && a.A == b.A
+}
@@ -0,0 +1,290 @@
+package smoothlife
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestConstructSimulationValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		modify  func(o *Options)
+		wantErr bool
+	}{
+		{"Defaults", func(o *Options) {}, false},
+		{"Zero width", func(o *Options) { o.Width = 0 }, true},
+		{"Negative inner radius", func(o *Options) { o.InnerRadius = -1 }, true},
+		{"Outer inside inner", func(o *Options) { o.OuterRadius = o.InnerRadius }, true},
+		{"Negative logres", func(o *Options) { o.LogRes = -1 }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Width, opts.Height = 32, 32
+			opts.InnerRadius, opts.OuterRadius = 2, 6
+			tc.modify(&opts)
+			_, err := ConstructSimulation(opts)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ConstructSimulation(%+v) error = %v; wantErr %v", opts, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndependentSimulations(t *testing.T) {
+	small := DefaultOptions()
+	small.Width, small.Height = 32, 16
+	small.InnerRadius, small.OuterRadius = 2, 6
+	large := small
+	large.Width, large.Height = 64, 48
+
+	a, err := ConstructSimulation(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ConstructSimulation(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.AddSpeckles()
+	a.Step()
+	b.Step()
+
+	if r, c := a.Field().Dims(); r != 16 || c != 32 {
+		t.Errorf("small simulation field is %dx%d; want 16x32", r, c)
+	}
+	if r, c := b.Field().Dims(); r != 48 || c != 64 {
+		t.Errorf("large simulation field is %dx%d; want 48x64", r, c)
+	}
+	if sum := SumDenseMatrix(b.Field()); sum != 0 {
+		t.Errorf("stepping an empty simulation produced mass %v; want 0", sum)
+	}
+}
+
+func TestSeeding(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 32
+	opts.InnerRadius, opts.OuterRadius = 2, 6
+
+	run := func(seed int64) *Simulation {
+		o := opts
+		o.Seed = seed
+		sim, err := ConstructSimulation(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.AddSpeckles()
+		for i := 0; i < 3; i++ {
+			sim.Step()
+		}
+		return sim
+	}
+
+	a, b, c := run(42), run(42), run(43)
+	if !mat.Equal(a.Field(), b.Field()) {
+		t.Error("two runs with the same seed differ")
+	}
+	if mat.Equal(a.Field(), c.Field()) {
+		t.Error("two runs with different seeds are identical")
+	}
+	if a.Seed() != 42 || a.Options().Seed != 42 {
+		t.Errorf("Seed() = %d, Options().Seed = %d; want 42", a.Seed(), a.Options().Seed)
+	}
+	if clock := run(0); clock.Seed() == 0 || clock.Options().Seed != clock.Seed() {
+		t.Errorf("seed 0 resolved to %d, recorded as %d", clock.Seed(), clock.Options().Seed)
+	}
+
+	// Reseeding restarts the sequence
+	a.Reseed(42)
+	first := a.Rand().Int63()
+	a.Reseed(42)
+	if a.Rand().Int63() != first {
+		t.Error("Reseed did not restart the random sequence")
+	}
+}
+
+func TestTimeStepModes(t *testing.T) {
+	base := DefaultOptions()
+	base.Width, base.Height = 32, 32
+	base.InnerRadius, base.OuterRadius = 2, 6
+
+	for mode := range timeStepModeNames {
+		for integrator := range integratorNames {
+			ts := TimeStep{Mode: mode, Integrator: integrator, Dt: 0.2}
+			if ts.Validate() != nil {
+				// The inner modes with RK4, see TestTimeStepValidation
+				continue
+			}
+			t.Run(mode.String()+"/"+integrator.String(), func(t *testing.T) {
+				opts := base
+				opts.TimeStep = ts
+				sim, err := ConstructSimulation(opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sim.AddSpeckles()
+				before := mat.DenseCopyOf(sim.Field())
+				sim.convolve([]*mat.Dense{before})
+				n, m := mat.DenseCopyOf(sim.ws.n[0]), mat.DenseCopyOf(sim.ws.m[0])
+				s := mat.NewDense(32, 32, nil)
+				sim.rules[0].Apply(s, n, m, before)
+
+				after := sim.Step()
+				r, c := after.Dims()
+				for i := 0; i < r; i++ {
+					for j := 0; j < c; j++ {
+						v := after.At(i, j)
+						if v < 0 || v > 1 {
+							t.Fatalf("field(%d, %d) = %v; want a value in [0,1]", i, j, v)
+						}
+						if integrator != Euler {
+							continue
+						}
+						f := before.At(i, j)
+						want := s.At(i, j)
+						if mode != Discrete {
+							want = Clamp(f+ts.Dt*ts.derivative(f, s.At(i, j), m.At(i, j)), 0, 1)
+						}
+						if !almostEqual(v, want, 1e-9) {
+							t.Fatalf("field(%d, %d) = %v; want %v", i, j, v, want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTimeStepValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		ts      TimeStep
+		wantErr bool
+	}{
+		{"Discrete ignores dt", TimeStep{Mode: Discrete}, false},
+		{"Smooth with dt", TimeStep{Mode: SmoothRelax, Integrator: RK4, Dt: 0.1}, false},
+		{"Smooth without dt", TimeStep{Mode: SmoothRelax}, true},
+		{"Dt above one", TimeStep{Mode: SmoothSigned, Dt: 1.5}, true},
+		{"Unknown mode", TimeStep{Mode: TimeStepMode(42), Dt: 0.1}, true},
+		{"Unknown integrator", TimeStep{Mode: SmoothSigned, Integrator: Integrator(42), Dt: 0.1}, true},
+		{"Inner with Euler", TimeStep{Mode: SmoothRelaxInner, Integrator: Euler, Dt: 0.1}, false},
+		{"Signed inner with RK4", TimeStep{Mode: SmoothSignedInner, Integrator: RK4, Dt: 0.1}, true},
+		{"Relax inner with RK4", TimeStep{Mode: SmoothRelaxInner, Integrator: RK4, Dt: 0.1}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.ts.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("%+v.Validate() = %v; wantErr %v", tc.ts, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIncrementalRule(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 32
+	opts.InnerRadius, opts.OuterRadius = 2, 6
+	// Halve the field every step regardless of the neighbourhood
+	opts.Rule = RuleFunc(func(n, m, f float64) float64 { return f / 2 })
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.AddSpeckles()
+	before := SumDenseMatrix(sim.Field())
+	sim.Step()
+	if after := SumDenseMatrix(sim.Field()); !almostEqual(after, before/2, 1e-9) {
+		t.Errorf("mass after an incremental halving step = %v; want %v", after, before/2)
+	}
+}
+
+func TestRuleRegistry(t *testing.T) {
+	rule, err := NewRule("basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rule.(*BasicRules); !ok {
+		t.Errorf("NewRule(\"basic\") = %T; want *BasicRules", rule)
+	}
+	if _, err := NewRule("no-such-rule"); err == nil {
+		t.Error("NewRule(\"no-such-rule\") succeeded; want an error")
+	}
+}
+
+func TestCrossChannelKernels(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 32, 32
+	opts.Channels = 2
+	opts.Kernels = []Kernel{{Source: 0, InnerRadius: 2, OuterRadius: 6, LogRes: 0.5}}
+	// Channel 0 holds still, channel 1 becomes the inner density of channel 0
+	opts.Weights = [][]float64{{0}, {1}}
+	opts.ChannelRules = []Rule{
+		RuleFunc(func(n, m, f float64) float64 { return f }),
+		RuleFunc(func(n, m, f float64) float64 { return m }),
+	}
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Channel(0).Set(10, 10, 1)
+	sim.Step()
+
+	if got := SumDenseMatrix(sim.Channel(0)); !almostEqual(got, 1, 1e-9) {
+		t.Errorf("channel 0 mass = %v; want 1", got)
+	}
+	if got := SumDenseMatrix(sim.Channel(1)); !almostEqual(got, 1, 1e-9) {
+		t.Errorf("channel 1 mass = %v; want the mass of channel 0", got)
+	}
+	if got := sim.Channel(1).At(10, 10); got <= 0 {
+		t.Errorf("channel 1 at the source cell = %v; want a positive density", got)
+	}
+
+	opts.Weights = [][]float64{{1}}
+	if _, err := ConstructSimulation(opts); err == nil {
+		t.Error("ConstructSimulation accepted a weight matrix with a missing channel row")
+	}
+	opts.Weights = nil
+	opts.Kernels[0].Source = 2
+	if _, err := ConstructSimulation(opts); err == nil {
+		t.Error("ConstructSimulation accepted a kernel reading a missing channel")
+	}
+}
+
+func TestStepDoesNotAllocate(t *testing.T) {
+	cases := []struct {
+		name     string
+		channels int
+		timeStep TimeStep
+		boundary Boundary
+	}{
+		{"Discrete", 1, TimeStep{Mode: Discrete}, BoundaryPeriodic},
+		{"Euler", 1, TimeStep{Mode: SmoothRelax, Integrator: Euler, Dt: 0.1}, BoundaryPeriodic},
+		{"RK4", 1, TimeStep{Mode: SmoothSigned, Integrator: RK4, Dt: 0.1}, BoundaryPeriodic},
+		{"Two channels", 2, TimeStep{Mode: SmoothRelax, Integrator: RK4, Dt: 0.1}, BoundaryPeriodic},
+		{"Reflective boundary", 1, TimeStep{Mode: Discrete}, BoundaryReflect},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Width, opts.Height = 32, 24
+			opts.InnerRadius, opts.OuterRadius = 2, 6
+			opts.Channels = tc.channels
+			opts.TimeStep = tc.timeStep
+			opts.Boundary = tc.boundary
+			sim, err := ConstructSimulation(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sim.AddSpeckles()
+			sim.Step()
+			if allocs := testing.AllocsPerRun(10, func() { sim.Step() }); allocs != 0 {
+				t.Errorf("Step allocated %v times per run; want 0", allocs)
+			}
+		})
+	}
+}
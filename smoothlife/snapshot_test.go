@@ -0,0 +1,246 @@
+package smoothlife
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func snapshotOptions() []Options {
+	smooth := DefaultOptions()
+	smooth.Width, smooth.Height = 32, 24
+	smooth.InnerRadius, smooth.OuterRadius = 2, 6
+	smooth.TimeStep = TimeStep{Mode: SmoothRelax, Integrator: RK4, Dt: 0.2}
+	smooth.Boundary = BoundaryFixed
+	smooth.BoundaryValue = 0.25
+	smooth.Rule = BasicRules{B1: 0.278, B2: 0.365, D1: 0.267, D2: 0.445, N: 0.028, M: 0.147, MixSigmoid: SigmoidCubic}
+
+	lenia := LeniaOptions()
+	lenia.Width, lenia.Height = 32, 32
+	lenia.Lenia = &LeniaKernel{R: 8, B: []float64{1, 0.5}, Core: CorePolynomial}
+
+	multi := smooth
+	multi.Channels = 2
+	multi.Kernels = []Kernel{
+		{Source: 0, InnerRadius: 2, OuterRadius: 6, LogRes: 1},
+		{Source: 1, Lenia: &LeniaKernel{R: 5, B: []float64{1}}},
+	}
+	multi.Weights = [][]float64{{0.7, 0.3}, {0.4, 0.6}}
+	multi.ChannelRules = []Rule{smooth.Rule, &BasicRules{B1: 0.25, B2: 0.35, D1: 0.25, D2: 0.45, N: 0.03, M: 0.15}}
+	return []Options{smooth, lenia, multi}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for i, opts := range snapshotOptions() {
+		sim, err := ConstructSimulation(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Reseed(int64(i + 1))
+		sim.AddSpeckles()
+		sim.Step()
+		sim.Step()
+
+		var buf bytes.Buffer
+		if err := sim.Save(&buf); err != nil {
+			t.Fatalf("options %d: Save: %v", i, err)
+		}
+		loaded, err := LoadSimulation(&buf)
+		if err != nil {
+			t.Fatalf("options %d: LoadSimulation: %v", i, err)
+		}
+		if loaded.StepCount() != 2 {
+			t.Errorf("options %d: loaded step count = %d; want 2", i, loaded.StepCount())
+		}
+		if loaded.Seed() != sim.Seed() || loaded.Rand().Int63() != sim.Rand().Int63() {
+			t.Errorf("options %d: the random source was not restored", i)
+		}
+		// Resuming must be bit-for-bit identical to never having stopped
+		sim.Step()
+		loaded.Step()
+		for c := 0; c < sim.Channels(); c++ {
+			if !mat.Equal(sim.Channel(c), loaded.Channel(c)) {
+				t.Errorf("options %d: channel %d differs after resuming from a snapshot", i, c)
+			}
+		}
+	}
+}
+
+func TestSnapshotAfterChannelRuleChange(t *testing.T) {
+	opts := snapshotOptions()[2]
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Reseed(3)
+	sim.AddSpeckles()
+	sim.Step()
+	changed := BasicRules{B1: 0.2, B2: 0.4, D1: 0.2, D2: 0.5, N: 0.028, M: 0.147}
+	if err := sim.SetChannelRule(1, changed); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetChannelRule(2, changed); err == nil {
+		t.Error("SetChannelRule of a missing channel succeeded")
+	}
+
+	var buf bytes.Buffer
+	if err := sim.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSimulation(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := loaded.Options().ChannelRules; len(rules) != 2 || rules[1] != Rule(changed) {
+		t.Errorf("loaded channel rules %v; want channel 1 on %+v", rules, changed)
+	}
+	sim.Step()
+	loaded.Step()
+	for c := 0; c < sim.Channels(); c++ {
+		if !mat.Equal(sim.Channel(c), loaded.Channel(c)) {
+			t.Errorf("channel %d differs after resuming from a snapshot", c)
+		}
+	}
+}
+
+func TestLoadInPlace(t *testing.T) {
+	opts := snapshotOptions()[0]
+	saved, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved.Reseed(5)
+	saved.AddSpeckles()
+	saved.Step()
+	var buf bytes.Buffer
+	if err := saved.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.OuterRadius = 8
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, clear := sim.SmoothLife, sim.Clear
+	if err := sim.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if sim.SmoothLife != engine {
+		t.Error("Load replaced the engine instead of changing it in place")
+	}
+	if sim.Seed() != 5 || sim.Options().OuterRadius != 6 || sim.StepCount() != 1 {
+		t.Errorf("loaded seed %d, options %+v at step %d", sim.Seed(), sim.Options(), sim.StepCount())
+	}
+	if !mat.Equal(sim.Field(), saved.Field()) {
+		t.Error("the field was not loaded")
+	}
+	// A method value taken before the load acts on the loaded simulation
+	clear()
+	if mat.Sum(sim.Field()) != 0 {
+		t.Error("a method value taken before Load missed the simulation")
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	opts := snapshotOptions()[0]
+	sim, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := sim.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+
+	corrupt := append([]byte(nil), good...)
+	corrupt[len(corrupt)-100] ^= 0xff
+	truncated := good[:len(good)-10]
+	wrongMagic := append([]byte("NOPE"), good[4:]...)
+	wrongVersion := append([]byte(nil), good...)
+	wrongVersion[4] = snapshotVersion + 1
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"Corrupt field", corrupt, "checksum"},
+		{"Truncated", truncated, "unexpected EOF"},
+		{"Wrong magic", wrongMagic, "not a snapshot"},
+		{"Wrong version", wrongVersion, "unsupported snapshot version 2"},
+		{"Empty", nil, "unexpected EOF"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := mat.DenseCopyOf(sim.Field())
+			err := sim.Load(bytes.NewReader(tc.data))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load = %v; want an error containing %q", err, tc.want)
+			}
+			if !mat.Equal(before, sim.Field()) {
+				t.Error("a failed Load changed the simulation")
+			}
+		})
+	}
+
+	opts.Rule = RuleFunc(func(n, m, f float64) float64 { return f })
+	custom, err := ConstructSimulation(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := custom.Save(&bytes.Buffer{}); err == nil {
+		t.Error("Save accepted a rule that cannot be restored")
+	}
+}
+
+// forgeGrid returns a copy of a snapshot whose header claims a width by height grid
+func forgeGrid(snapshot []byte, width, height uint32) []byte {
+	forged := append([]byte(nil), snapshot...)
+	binary.LittleEndian.PutUint32(forged[6:], width)
+	binary.LittleEndian.PutUint32(forged[10:], height)
+	return forged
+}
+
+func TestSnapshotForgedSize(t *testing.T) {
+	sim, err := ConstructSimulation(snapshotOptions()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := sim.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"Largest grid, truncated", forgeGrid(buf.Bytes(), 4096, 4096), "unexpected EOF"},
+		{"Too wide", forgeGrid(buf.Bytes(), 1<<25, 1), "too large"},
+		{"Too many cells", forgeGrid(buf.Bytes(), 1<<16, 1<<16), "too large"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := LoadSimulation(bytes.NewReader(tc.data))
+			runtime.ReadMemStats(&after)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("LoadSimulation = %v; want an error containing %q", err, tc.want)
+			}
+			// The forged grid would need 128 MiB
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+				t.Errorf("LoadSimulation allocated %d bytes for a %d byte snapshot", allocated, len(tc.data))
+			}
+		})
+	}
+}
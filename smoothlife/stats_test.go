@@ -0,0 +1,26 @@
+package smoothlife
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestFieldStats(t *testing.T) {
+	cases := []struct {
+		name  string
+		field *mat.Dense
+		want  Stats
+	}{
+		{"Empty", mat.NewDense(2, 2, nil), Stats{Mass: 0, Alive: 0, Min: 0, Max: 0}},
+		{"Mixed", mat.NewDense(2, 2, []float64{0, 0.5, 0.75, 1}), Stats{Mass: 0.5625, Alive: 0.5, Min: 0, Max: 1}},
+		{"View", mat.NewDense(3, 3, []float64{1, 1, 9, 1, 0, 9, 9, 9, 9}).Slice(0, 2, 0, 2).(*mat.Dense), Stats{Mass: 0.75, Alive: 0.75, Min: 0, Max: 1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FieldStats(tc.field); got != tc.want {
+				t.Errorf("FieldStats = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
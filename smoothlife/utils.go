@@ -1,4 +1,4 @@
-package main
+package smoothlife
 
 import (
 	"log"
@@ -264,30 +264,6 @@ func ElementwiseMultiplyCDenseMatrices(A, B *mat.CDense) *mat.CDense {
 	return result
 }
 
-// Helper function which
-func sliceToCDense(slice [][]complex128) *mat.CDense {
-	rows := len(slice)
-	cols := len(slice[0])
-	data := make([]complex128, 0, rows*cols)
-	for _, row := range slice {
-		data = append(data, row...)
-	}
-	B := mat.NewCDense(rows, cols, data)
-	return B
-}
-
-func cdenseToSlice(A *mat.CDense) [][]complex128 {
-	r, c := A.Dims()
-	result := make([][]complex128, r)
-	for i := range result {
-		result[i] = make([]complex128, c)
-		for j := range result[i] {
-			result[i][j] = A.At(i, j)
-		}
-	}
-	return result
-}
-
 // RealPartCDenseMatrix gets the real part of a CDense and returns a Dense
 func RealPartCDenseMatrix(cd *mat.CDense) *mat.Dense {
 	r, c := cd.Dims()
@@ -1,4 +1,12 @@
-package main
+package smoothlife
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
 
 // func TestLogisticThreshold(t *testing.T) {
 // 	cases := []struct {
@@ -163,3 +171,118 @@ package main
 // 		})
 // 	}
 // }
+
+func TestSigmoidThreshold(t *testing.T) {
+	cases := []struct {
+		name         string
+		sigmoid      Sigmoid
+		x, x0, width float64
+		expected     float64
+	}{
+		{"Hard below", SigmoidHard, 0.25, 0.5, 0.1, 0.0},
+		{"Hard at x0", SigmoidHard, 0.5, 0.5, 0.1, 0.0},
+		{"Hard above", SigmoidHard, 0.75, 0.5, 0.1, 1.0},
+		{"Linear at x0", SigmoidLinear, 0.5, 0.5, 0.1, 0.5},
+		{"Linear in transition", SigmoidLinear, 0.525, 0.5, 0.1, 0.75},
+		{"Cubic at x0", SigmoidCubic, 0.5, 0.5, 0.1, 0.5},
+		{"Cubic in transition", SigmoidCubic, 0.525, 0.5, 0.1, 0.84375},
+		{"Cubic saturates", SigmoidCubic, 0.6, 0.5, 0.1, 1.0},
+		{"Sine at x0", SigmoidSine, 0.5, 0.5, 0.1, 0.5},
+		{"Sine saturates", SigmoidSine, 0.3, 0.5, 0.1, 0.0},
+		{"Default is logistic", SigmoidDefault, 0.75, 0.5, 0.1, LogisticThreshold(0.75, 0.5, 0.1)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.sigmoid.Threshold(tc.x, tc.x0, tc.width)
+			if !almostEqual(result, tc.expected, 1e-9) {
+				t.Errorf("%v.Threshold(%v, %v, %v) = %v; want %v", tc.sigmoid, tc.x, tc.x0, tc.width, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRFFT2RoundTrip(t *testing.T) {
+	cases := []struct {
+		name       string
+		rows, cols int
+	}{
+		{"Even", 8, 16},
+		{"Odd columns", 6, 9},
+		{"Odd rows", 7, 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newRFFT2(tc.rows, tc.cols)
+			input := make([]float64, tc.rows*tc.cols)
+			for i := range input {
+				input[i] = float64((i*7)%5) - 1.5
+			}
+			spectrum := make([]complex128, tc.rows*f.half)
+			f.forward(spectrum, input)
+			// The DC coefficient is the sum of the input
+			if want := SumDenseMatrix(mat.NewDense(tc.rows, tc.cols, input)); !almostEqual(real(spectrum[0]), want, 1e-9) {
+				t.Errorf("DC coefficient = %v; want %v", spectrum[0], want)
+			}
+			output := make([]float64, len(input))
+			f.inverse(output, spectrum)
+			for i := range input {
+				if !almostEqual(output[i], input[i], 1e-12) {
+					t.Fatalf("round trip element %d = %v; want %v", i, output[i], input[i])
+				}
+			}
+		})
+	}
+}
+
+// naiveDFT2 is the 2D discrete Fourier transform of a rows x cols real field,
+// straight from its definition
+func naiveDFT2(input []float64, rows int, cols int) [][]complex128 {
+	out := make([][]complex128, rows)
+	for k := range out {
+		out[k] = make([]complex128, cols)
+		for l := range out[k] {
+			for i := 0; i < rows; i++ {
+				for j := 0; j < cols; j++ {
+					angle := -2 * math.Pi * (float64(k*i)/float64(rows) + float64(l*j)/float64(cols))
+					out[k][l] += complex(input[i*cols+j], 0) * cmplx.Exp(complex(0, angle))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestRFFT2MatchesDFT(t *testing.T) {
+	cases := []struct {
+		name       string
+		rows, cols int
+	}{
+		{"Even", 4, 6},
+		{"Odd", 5, 7},
+		{"Odd rows", 3, 8},
+		{"Odd columns", 6, 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := make([]float64, tc.rows*tc.cols)
+			for i := range input {
+				// No symmetry that could hide a misplaced coefficient
+				input[i] = math.Sin(float64(i*i)*0.37) + float64(i%3)
+			}
+			f := newRFFT2(tc.rows, tc.cols)
+			spectrum := make([]complex128, tc.rows*f.half)
+			f.forward(spectrum, input)
+			want := naiveDFT2(input, tc.rows, tc.cols)
+			for k := 0; k < tc.rows; k++ {
+				for l := 0; l < f.half; l++ {
+					if got := spectrum[k*f.half+l]; cmplx.Abs(got-want[k][l]) > 1e-9 {
+						t.Errorf("coefficient (%d, %d) = %v; want %v", k, l, got, want[k][l])
+					}
+				}
+			}
+		})
+	}
+}